@@ -0,0 +1,86 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// assignOperators maps compound assignment operators to their binary operator
+var assignOperators = map[token.Token]token.Token{
+	token.ADD_ASSIGN:     token.ADD,
+	token.SUB_ASSIGN:     token.SUB,
+	token.MUL_ASSIGN:     token.MUL,
+	token.QUO_ASSIGN:     token.QUO,
+	token.AND_ASSIGN:     token.AND,
+	token.OR_ASSIGN:      token.OR,
+	token.XOR_ASSIGN:     token.XOR,
+	token.AND_NOT_ASSIGN: token.AND_NOT,
+	token.SHL_ASSIGN:     token.SHL,
+	token.SHR_ASSIGN:     token.SHR,
+}
+
+// assignToken returns the compound assignment operator for the binary operator op
+func assignToken(op token.Token) token.Token {
+	for assign, binary := range assignOperators {
+		if binary == op {
+			return assign
+		}
+	}
+	return token.ILLEGAL
+}
+
+// AssignStmtVisitor finds compound assignments and increment/decrement statements
+// whose operators can be swapped
+type AssignStmtVisitor struct {
+	// Categories is a set of operator categories to consider for mutation
+	Categories map[string]bool
+
+	// Info is used to skip mutants that are invalid for the operand types, if non-nil
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+}
+
+func (v *AssignStmtVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.AssignStmt:
+		binary, ok := assignOperators[n.Tok]
+		if !ok {
+			break
+		}
+		m, ok := operators[binary]
+		if !ok || !v.Categories[m.category] {
+			break
+		}
+		for _, repl := range m.replacements {
+			if validOperator(v.Info, n.Lhs[0], repl) {
+				v.swap(n.TokPos, &n.Tok, assignToken(repl), m.category)
+			}
+		}
+	case *ast.IncDecStmt:
+		if !v.Categories["arithmetic"] {
+			break
+		}
+		repl := token.INC
+		if n.Tok == token.INC {
+			repl = token.DEC
+		}
+		v.swap(n.TokPos, &n.Tok, repl, "arithmetic")
+	}
+	return v
+}
+
+// swap records a mutation that replaces the token pointed to by tok with repl
+func (v *AssignStmtVisitor) swap(pos token.Pos, tok *token.Token, repl token.Token, category string) {
+	orig := *tok
+	v.Mutants = append(v.Mutants, Mutant{
+		Pos:         pos,
+		Category:    category,
+		Original:    orig.String(),
+		Replacement: repl.String(),
+		Apply:       func() { *tok = repl },
+		Revert:      func() { *tok = orig },
+	})
+}
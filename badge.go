@@ -0,0 +1,83 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+)
+
+// badgeLabel is the label on the left of a mutation score badge
+const badgeLabel = "mutation score"
+
+// badgeColor is the color of a badge for scores of at least min percent
+type badgeColor struct {
+	min  float64
+	name string
+	hex  string
+}
+
+// badgeColors are the colors of badges by score, using the names and colors of shields.io
+var badgeColors = []badgeColor{
+	{90, "brightgreen", "#4c1"},
+	{75, "green", "#97ca00"},
+	{60, "yellow", "#dfb317"},
+	{40, "orange", "#fe7d37"},
+	{0, "red", "#e05d44"},
+}
+
+// badge returns the message and color of a badge for the summary s
+func badge(s Summary) (message string, color badgeColor) {
+	if s[Killed]+s[Survived] == 0 {
+		return "unknown", badgeColor{name: "lightgrey", hex: "#9f9f9f"}
+	}
+	score := 100 * s.Score()
+	for _, color = range badgeColors {
+		if score >= color.min {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f%%", score), color
+}
+
+// WriteBadge writes an SVG badge showing the mutation score of s to w, colored
+// by how high it is
+func WriteBadge(w io.Writer, s Summary) error {
+	message, color := badge(s)
+
+	// Text widths are estimated, as Verdana isn't available to measure them
+	textWidth := func(text string) int { return 7*len(text) + 10 }
+	left, right := textWidth(badgeLabel), textWidth(message)
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="%[2]d" height="20" fill="#555"/>
+<rect x="%[2]d" width="%[5]d" height="20" fill="%[6]s"/>
+<rect width="%[1]d" height="20" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[3]s</text>
+<text x="%[7]d" y="14">%[3]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text>
+<text x="%[8]d" y="14">%[4]s</text>
+</g>
+</svg>
+`, left+right, left, html.EscapeString(badgeLabel), html.EscapeString(message), right, color.hex, left/2, left+right/2)
+	return err
+}
+
+// WriteShieldsEndpoint writes the mutation score of s to w as JSON for a
+// shields.io endpoint badge
+func WriteShieldsEndpoint(w io.Writer, s Summary) error {
+	message, color := badge(s)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, badgeLabel, message, color.name})
+}
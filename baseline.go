@@ -0,0 +1,116 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Baseline is a file listing surviving mutants that were accepted, because they
+// are equivalent to the original code or not worth killing, so that they can be
+// told apart from new survivors. It's meant to be checked in with the code.
+type Baseline struct {
+	// Path is the name of the baseline file
+	Path string
+
+	Mutants []BaselineEntry
+}
+
+// BaselineEntry identifies an accepted mutant
+type BaselineEntry struct {
+	Package     string `json:"package"`
+	ID          string `json:"id"`
+	Category    string `json:"category"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+}
+
+// Key identifies the mutant the same way as MutantRecord.Key
+func (e BaselineEntry) Key() string {
+	return fmt.Sprintf("%s %s %s %s -> %s", e.Package, e.ID, e.Category, e.Original, e.Replacement)
+}
+
+// baselineEntry returns the entry accepting the mutant of result
+func baselineEntry(result Result) BaselineEntry {
+	return BaselineEntry{
+		Package:     result.Package,
+		ID:          result.ID,
+		Category:    result.Mutant.Category,
+		Original:    result.Mutant.Original,
+		Replacement: result.Mutant.Replacement,
+	}
+}
+
+// LoadBaseline reads the baseline file at path. A missing file is an empty baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	b := &Baseline{Path: path}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &b.Mutants); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", path, err)
+	}
+	return b, nil
+}
+
+// Contains reports whether the mutant of result was accepted
+func (b *Baseline) Contains(result Result) bool {
+	key := baselineEntry(result).Key()
+	for _, e := range b.Mutants {
+		if e.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Add accepts the mutant of result, if it isn't already
+func (b *Baseline) Add(result Result) {
+	if !b.Contains(result) {
+		b.Mutants = append(b.Mutants, baselineEntry(result))
+	}
+}
+
+// Remove removes the mutant of result from the baseline
+func (b *Baseline) Remove(result Result) {
+	key := baselineEntry(result).Key()
+	var kept []BaselineEntry
+	for _, e := range b.Mutants {
+		if e.Key() != key {
+			kept = append(kept, e)
+		}
+	}
+	b.Mutants = kept
+}
+
+// Report updates the baseline with the result of a run: surviving mutants are
+// accepted, and killed ones are removed
+func (b *Baseline) Report(result Result) {
+	switch result.Outcome {
+	case Survived, Suppressed:
+		b.Add(result)
+	case Killed:
+		b.Remove(result)
+	}
+}
+
+// Save writes the baseline to its file, sorted so that it diffs well
+func (b *Baseline) Save() error {
+	sort.Slice(b.Mutants, func(i, j int) bool {
+		return b.Mutants[i].Key() < b.Mutants[j].Key()
+	})
+	mutants := b.Mutants
+	if mutants == nil {
+		mutants = []BaselineEntry{}
+	}
+	data, err := json.MarshalIndent(mutants, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.Path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,93 @@
+package mutator
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// BuildConfig holds the build settings used both to load packages and to run their tests
+type BuildConfig struct {
+	// Tags are additional build tags to consider satisfied
+	Tags []string
+
+	// GOOS and GOARCH select the target operating system and architecture.
+	// The defaults of the go tool are used for those that are empty.
+	GOOS, GOARCH string
+
+	// GOCACHE, if non-empty, is the build cache used by the go tool. It's set
+	// explicitly so that every command run for a package shares the same
+	// compiled dependencies, whatever the environment of the command.
+	GOCACHE string
+}
+
+// Context returns a build context which selects files the same way as the go tool
+func (b BuildConfig) Context() *build.Context {
+	ctxt := build.Default
+	ctxt.BuildTags = append(append([]string{}, ctxt.BuildTags...), b.Tags...)
+	if b.GOOS != "" {
+		ctxt.GOOS = b.GOOS
+	}
+	if b.GOARCH != "" {
+		ctxt.GOARCH = b.GOARCH
+	}
+	return &ctxt
+}
+
+// buildCommands are the subcommands of the go tool that accept build flags
+var buildCommands = map[string]bool{
+	"build": true, "install": true, "list": true, "run": true, "test": true, "vet": true,
+}
+
+// Command returns a command running the go tool with the given arguments, the
+// first of which is the subcommand. The build settings are passed in the
+// environment, and as flags following the subcommand if it accepts them.
+func (b BuildConfig) Command(ctx context.Context, args ...string) *exec.Cmd {
+	if len(b.Tags) > 0 && len(args) > 0 && buildCommands[args[0]] {
+		args = append([]string{args[0], "-tags=" + strings.Join(b.Tags, ",")}, args[1:]...)
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = os.Environ()
+	if b.GOOS != "" {
+		cmd.Env = append(cmd.Env, "GOOS="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		cmd.Env = append(cmd.Env, "GOARCH="+b.GOARCH)
+	}
+	if b.GOCACHE != "" {
+		cmd.Env = append(cmd.Env, "GOCACHE="+b.GOCACHE)
+	}
+	return cmd
+}
+
+// goCache returns the build cache the go tool uses by default
+func goCache() (string, error) {
+	out, err := exec.Command("go", "env", "GOCACHE").Output()
+	if err != nil {
+		return "", fmt.Errorf("could not determine build cache: %s", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// warmCache builds the tests of the package in pkgDir with testFlags without
+// running any of them, so that the package's dependencies are compiled once
+// into the build cache rather than by the tests of the first mutants. If d is
+// non-nil the cache of its containers is warmed instead.
+func warmCache(ctx context.Context, b BuildConfig, d *Docker, pkgDir string, testFlags []string) error {
+	args := append(append([]string{"test"}, testFlags...), "-run=^$")
+	outcome, output, err := goTest(ctx, b, d, pkgDir, nil, args)
+	if err != nil {
+		return err
+	}
+	if outcome != Survived {
+		return fmt.Errorf("could not build tests:\n%s", output)
+	}
+	return nil
+}
+
+func (b BuildConfig) String() string {
+	return "tags=" + strings.Join(b.Tags, ",") + " GOOS=" + b.GOOS + " GOARCH=" + b.GOARCH
+}
@@ -0,0 +1,161 @@
+package mutator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cache stores the outcomes of tested mutations, and what killed them, on disk so that mutations whose
+// inputs haven't changed don't need to be tested again.
+type Cache struct {
+	// Dir is the directory holding the cached outcomes
+	Dir string
+
+	goVersion string
+	testKeys  map[string]string
+}
+
+// Key returns the cache key for the mutated contents src of the file srcFile in pkg.
+// It covers the mutated file, the package's other files, its test files and the
+// packages imported only by them, the compiled dependencies of the package, the
+// build settings and test flags, and the version of the go tool.
+func (c *Cache) Key(pkg *Package, testFlags []string, srcFile string, src []byte) (string, error) {
+	if c.goVersion == "" {
+		out, err := exec.Command("go", "env", "GOVERSION").Output()
+		if err != nil {
+			return "", fmt.Errorf("could not determine go version: %s", err)
+		}
+		c.goVersion = strings.TrimSpace(string(out))
+	}
+
+	testKey, ok := c.testKeys[pkg.Dir]
+	if !ok {
+		var err error
+		if testKey, err = hashPackage(pkg); err != nil {
+			return "", err
+		}
+		if c.testKeys == nil {
+			c.testKeys = make(map[string]string)
+		}
+		c.testKeys[pkg.Dir] = testKey
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n%q\n%s %d\n", c.goVersion, testKey, pkg.Build, testFlags, srcFile, len(src))
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get returns the cached outcome for key and the mechanism that killed the
+// mutant, if there is one
+func (c *Cache) Get(key string) (Outcome, string, bool) {
+	data, err := ioutil.ReadFile(filepath.Join(c.Dir, key))
+	if err != nil {
+		return 0, "", false
+	}
+	// Entries written before the mechanism was recorded hold only the outcome
+	s, killedBy, _ := strings.Cut(string(data), "\n")
+	outcome, err := ParseOutcome(s)
+	return outcome, killedBy, err == nil
+}
+
+// Put records the outcome for key and the mechanism that killed the mutant
+func (c *Cache) Put(key string, outcome Outcome, killedBy string) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("could not create cache directory: %s", err)
+	}
+	return ioutil.WriteFile(filepath.Join(c.Dir, key), []byte(outcome.String()+"\n"+killedBy), 0644)
+}
+
+// hashPackage returns a hash of the source files of pkg, its test files and its
+// testdata directory, the Go files of the packages outside the standard library
+// that are imported only by its tests, such as test helpers, and the export data
+// of the dependencies of pkg. The test helpers' own dependencies are not included.
+func hashPackage(pkg *Package) (string, error) {
+	h := sha256.New()
+	hashFiles := func(dir string, names []string) error {
+		for _, name := range names {
+			data, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s %d\n", filepath.Join(dir, name), len(data))
+			h.Write(data)
+		}
+		return nil
+	}
+
+	var files []string
+	for _, names := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.SFiles, pkg.CFiles, pkg.HFiles, pkg.TestGoFiles, pkg.XTestGoFiles} {
+		files = append(files, names...)
+	}
+	if err := hashFiles(pkg.Dir, files); err != nil {
+		return "", err
+	}
+
+	var testdata []string
+	err := filepath.Walk(filepath.Join(pkg.Dir, "testdata"), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.Mode().IsRegular() {
+			rel, _ := filepath.Rel(pkg.Dir, path)
+			testdata = append(testdata, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := hashFiles(pkg.Dir, testdata); err != nil {
+		return "", err
+	}
+
+	// The export data of a package is named after a hash of its contents, so
+	// listing it covers changes to the dependencies without reading them
+	cmd := pkg.Build.Command(context.Background(), "list", "-export", "-deps",
+		"-f", "{{if not .Standard}}{{.ImportPath}} {{.Export}}{{end}}", ".")
+	cmd.Dir = pkg.Dir
+	deps, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not list dependencies of %s: %s", pkg.ImportPath, err)
+	}
+	h.Write(deps)
+
+	imported := map[string]bool{pkg.ImportPath: true}
+	for _, path := range pkg.Imports {
+		imported[path] = true
+	}
+	var testImports []string
+	for _, path := range append(append([]string{}, pkg.TestImports...), pkg.XTestImports...) {
+		if !imported[path] {
+			imported[path] = true
+			testImports = append(testImports, path)
+		}
+	}
+	sort.Strings(testImports)
+	for _, path := range testImports {
+		dep, err := pkg.Build.Context().Import(path, pkg.Dir, 0)
+		if err != nil {
+			return "", fmt.Errorf("could not import test dependency %s: %s", path, err)
+		}
+		if dep.Goroot {
+			continue
+		}
+		if err := hashFiles(dep.Dir, dep.GoFiles); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
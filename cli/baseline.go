@@ -0,0 +1,17 @@
+package cli
+
+import "path/filepath"
+
+// baselineName is the name of the baseline file searched for in the current
+// directory and its parents
+const baselineName = ".mutator-baseline.json"
+
+// findBaseline returns the path of the nearest baseline file, or of a new one
+// at the root of the repository if there is none
+func findBaseline() (string, error) {
+	path, err := findFile(baselineName)
+	if path == "" && err == nil {
+		path = filepath.Join(repoRoot(), baselineName)
+	}
+	return path, err
+}
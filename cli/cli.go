@@ -0,0 +1,355 @@
+// Package cli implements the mutator command line interface. Programs that
+// register their own mutators with mutator.Register can call Main to make
+// them available to the -categories flag.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kisielk/mutator"
+)
+
+const (
+	// exitLowScore is the exit status used when the mutation score is below -min-score
+	exitLowScore = 2
+
+	// exitInterrupted is the exit status used when a run is stopped by a signal
+	exitInterrupted = 130
+)
+
+func Err(s string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "error: "+s, args...)
+}
+
+func Errf(s string, args ...interface{}) {
+	Err(s, args...)
+	os.Exit(1)
+}
+
+// command is a subcommand of mutator
+type command struct {
+	// usage is the synopsis of the command's arguments
+	usage string
+
+	// summary describes the command in the list of commands
+	summary string
+
+	// main defines the command's flags on fs and returns the function running
+	// the command with its arguments
+	main func(fs *flag.FlagSet) func(args []string)
+}
+
+var commands map[string]command
+
+func init() {
+	// Initialized here because the usage of mutator refers to the commands
+	commands = map[string]command{
+		"run":     {"[flags] [packages] [testflags]", "test the mutants of packages (the default)", runMain},
+		"list":    {"[flags] [packages]", "list the mutation sites of packages", listMain},
+		"report":  {"[flags] file", "render the results stored by run -format json or -db", reportMain},
+		"show":    {"[flags] mutation-id|file:line:col [packages]", "print the diff of a mutant", showMain},
+		"history": {"[flags]", "show the score trend and regressions recorded with -db", historyMain},
+		"serve":   {"[flags] [packages] [testflags]", "test the mutants of packages on workers connecting over the network", serveMain},
+		"tui":     {"[flags] file [testflags]", "triage the surviving mutants stored by run -format json or -db", tuiMain},
+		"watch":   {"[flags] package [testflags]", "test the mutants of functions again as they change", watchMain},
+		"worker":  {"-connect host:port [flags]", "test mutants handed out by mutator serve", workerMain},
+	}
+}
+
+// Main runs the mutator command. The first argument selects a subcommand;
+// if it isn't one, the arguments are those of the run subcommand.
+func Main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			cmd.main(newFlagSet(args[0]))(args[1:])
+			return
+		}
+		if args[0] == "help" {
+			usage()
+			return
+		}
+	}
+	runMain(newFlagSet("run"))(args)
+}
+
+// usage describes the subcommands
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: mutator <command> [arguments]\n\nThe commands are:\n\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nUse mutator <command> -h for the flags of a command.\n")
+}
+
+// newFlagSet returns the flag set of the named subcommand
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: mutator %s %s\n", name, commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// mutantFlags are the flags that select the mutants of packages, shared by the
+// subcommands which load packages
+type mutantFlags struct {
+	categories   *string
+	include      *string
+	exclude      *string
+	minFuncLines *int
+	onlyExported *bool
+	changedSince *string
+	tags         *string
+	goos         *string
+	goarch       *string
+	gocache      *string
+	config       *string
+}
+
+func addMutantFlags(fs *flag.FlagSet) *mutantFlags {
+	return &mutantFlags{
+		categories: fs.String("categories", "comparison,logical,arithmetic,binary,statement,literal,negate-conditionals,error,return,unary,index",
+			"A comma-separated list of mutation categories to enable. All built-in categories are enabled by default.\n"+
+				"Available categories: "+strings.Join(mutator.Categories(), ", ")),
+		include: fs.String("include", "",
+			"A comma-separated list of glob patterns. Only files or functions matching one of them are mutated."),
+		exclude: fs.String("exclude", "",
+			"A comma-separated list of glob patterns. Files or functions matching any of them are not mutated."),
+		minFuncLines: fs.Int("min-func-lines", 0,
+			"Only mutate functions spanning at least this many lines, skipping getters and other trivial functions. Code outside of functions isn't mutated if set."),
+		onlyExported: fs.Bool("only-exported", false,
+			"Only mutate exported functions and the exported methods of exported types. Code outside of functions isn't mutated if set."),
+		changedSince: fs.String("changed-since", "",
+			"Only mutate lines changed since the given git ref, including every line of untracked files that aren't ignored. Use - to read a unified diff from stdin instead, with paths relative to the root of the git repository."),
+		tags:   fs.String("tags", "", "A comma-separated list of build tags to consider satisfied when loading and testing packages."),
+		goos:   fs.String("goos", "", "The target operating system used to select files and run the tests. Defaults to that of the go tool."),
+		goarch: fs.String("goarch", "", "The target architecture used to select files and run the tests. Defaults to that of the go tool."),
+		gocache: fs.String("gocache", "",
+			"The build cache shared by the go commands testing every mutation, which is warmed before they start. Defaults to that of the go tool."),
+		config: fs.String("config", "",
+			"The configuration file to read settings from. Defaults to the nearest "+configName+" in the current directory or its parents.\n"+
+				"Settings are named after flags, which override them, and test-flags holds the flags passed to go test."),
+	}
+}
+
+// parse parses the arguments of a subcommand and applies the configuration file
+// to the flags that weren't set. Package patterns come first, and the arguments
+// from the first flag after them on are returned as test flags, which are checked
+// to be valid go test flags. Arguments after -- are added to the test flags as they are.
+func (f *mutantFlags) parse(fs *flag.FlagSet, args []string) (patterns, testFlags []string, cfg config) {
+	var raw []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, raw = args[:i], args[i+1:]
+			break
+		}
+	}
+	fs.Parse(args)
+
+	args = fs.Args()
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			testFlags = args[i:]
+			break
+		}
+		patterns = append(patterns, arg)
+	}
+
+	if *f.config == "" {
+		path, err := findConfig()
+		if err != nil {
+			Errf("%s\n", err)
+		}
+		*f.config = path
+	}
+	if *f.config != "" {
+		var err error
+		if cfg, err = loadConfig(*f.config); err != nil {
+			Errf("could not read config: %s\n", err)
+		}
+		if err := cfg.check(); err != nil {
+			Errf("%s: %s\n", *f.config, err)
+		}
+		if err := cfg.apply(fs); err != nil {
+			Errf("%s: %s\n", *f.config, err)
+		}
+		if testFlags == nil {
+			testFlags = cfg["test-flags"]
+		}
+	}
+	if err := checkTestFlags(testFlags); err != nil {
+		Errf("%s\n", err)
+	}
+	return patterns, append(testFlags, raw...), cfg
+}
+
+// options returns the options selecting mutants according to the flags
+func (f *mutantFlags) options(testFlags []string) mutator.Options {
+	includePatterns, err := mutator.ParseFilterPatterns(*f.include)
+	if err != nil {
+		Errf("-include: %s\n", err)
+	}
+	excludePatterns, err := mutator.ParseFilterPatterns(*f.exclude)
+	if err != nil {
+		Errf("-exclude: %s\n", err)
+	}
+	if *f.minFuncLines < 0 {
+		Errf("-min-func-lines must not be negative\n")
+	}
+
+	opts := mutator.Options{
+		Build:      mutator.BuildConfig{GOOS: *f.goos, GOARCH: *f.goarch, GOCACHE: *f.gocache},
+		Categories: make(map[string]bool),
+		TestFlags:  testFlags,
+		Filter: mutator.Filter{
+			Include:      includePatterns,
+			Exclude:      excludePatterns,
+			MinFuncLines: *f.minFuncLines,
+			OnlyExported: *f.onlyExported,
+		},
+	}
+	if opts.Build.GOCACHE != "" {
+		// The go tool requires an absolute path
+		if opts.Build.GOCACHE, err = filepath.Abs(opts.Build.GOCACHE); err != nil {
+			Errf("-gocache: %s\n", err)
+		}
+	}
+	if *f.tags != "" {
+		opts.Build.Tags = strings.Split(*f.tags, ",")
+	}
+
+	known := make(map[string]bool)
+	for _, cat := range mutator.Categories() {
+		known[cat] = true
+	}
+	for _, cat := range strings.Split(*f.categories, ",") {
+		if !known[cat] {
+			Errf("unknown mutation category %q\n", cat)
+		}
+		opts.Categories[cat] = true
+	}
+
+	switch *f.changedSince {
+	case "":
+	case "-":
+		// Like those of git diff, the paths are relative to the root of the repository
+		if opts.Changed, err = mutator.ParseDiff(os.Stdin, repoRoot()); err != nil {
+			Errf("could not parse diff: %s\n", err)
+		}
+	default:
+		if opts.Changed, err = mutator.GitChangedLines(*f.changedSince); err != nil {
+			Errf("%s\n", err)
+		}
+	}
+	return opts
+}
+
+// runnerFlags are the flags that select where the tests of mutants are run,
+// shared by the subcommands which run them
+type runnerFlags struct {
+	runner      *string
+	image       *string
+	cacheVolume *string
+}
+
+func addRunnerFlags(fs *flag.FlagSet) *runnerFlags {
+	return &runnerFlags{
+		runner: fs.String("runner", "local",
+			"Where to run the tests of each mutation: local, or docker to run them in a container without network access\n"+
+				"where the source is mounted read-only, so mutations can't damage the machine."),
+		image: fs.String("image", "golang", "The container image used by -runner docker. It must provide the go tool."),
+		cacheVolume: fs.String("cache-volume", "mutator-go-build",
+			"The docker volume holding the build cache shared by the containers of -runner docker. If empty each container compiles the dependencies."),
+	}
+}
+
+// docker returns the container settings selected by the flags, or nil if the tests run locally
+func (f *runnerFlags) docker() *mutator.Docker {
+	switch *f.runner {
+	case "local":
+		return nil
+	case "docker":
+		if *f.image == "" {
+			Errf("-image must be set with -runner docker\n")
+		}
+		return &mutator.Docker{Image: *f.image, CacheVolume: *f.cacheVolume}
+	default:
+		Errf("unknown runner %q, must be local or docker\n", *f.runner)
+		return nil
+	}
+}
+
+// logFlags are the flags selecting how much is logged, and where
+type logFlags struct {
+	verbose *bool
+	quiet   *bool
+	path    *string
+}
+
+func addLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		verbose: fs.Bool("v", false, "Log the diff and test output of each mutation, and what is being done to test them."),
+		quiet:   fs.Bool("quiet", false, "Don't report progress or the outcome of each mutation, only the summaries."),
+		path:    fs.String("log", "", "Append the log to the given file instead of writing it to stderr."),
+	}
+}
+
+// level returns the verbosity selected by the flags
+func (f *logFlags) level() mutator.Level {
+	switch {
+	case *f.verbose && *f.quiet:
+		Errf("-v and -quiet can't be used together\n")
+	case *f.verbose:
+		return mutator.LevelVerbose
+	case *f.quiet:
+		return mutator.LevelQuiet
+	}
+	return mutator.LevelNormal
+}
+
+// logger returns a logger at the selected level writing to the log file, or to w
+// if there is none
+func (f *logFlags) logger(w io.Writer) *mutator.Logger {
+	if *f.path != "" {
+		file, err := os.OpenFile(*f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			Errf("could not open log: %s\n", err)
+		}
+		w = file
+	}
+	return &mutator.Logger{W: w, Level: f.level()}
+}
+
+// appendReporter returns a reporter passing results to both r, which may be nil, and other
+func appendReporter(r, other mutator.Reporter) mutator.Reporter {
+	if r == nil {
+		return other
+	}
+	return mutator.MultiReporter{r, other}
+}
+
+// writeReport creates the file at path and writes a report to it using write
+func writeReport(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configName is the name of the configuration file searched for in the
+// current directory and its parents
+const configName = ".mutator.toml"
+
+// findConfig returns the path of the nearest configuration file, or "" if there is none
+func findConfig() (string, error) {
+	return findFile(configName)
+}
+
+// findFile returns the path of the nearest file with the given name in the current
+// directory or its parents, or "" if there is none
+func findFile(name string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// config holds the settings read from a configuration file. Keys are flag
+// names, apart from test-flags which holds the flags passed to go test.
+type config map[string][]string
+
+// parseConfig reads a configuration file written in a subset of TOML: each line
+// assigns a string, number, boolean or array of those to a key. Tables are not supported.
+func parseConfig(r io.Reader) (config, error) {
+	cfg := make(config)
+	s := bufio.NewScanner(r)
+	var lineNum int
+	for s.Scan() {
+		lineNum++
+		line := stripComment(s.Text())
+		if line == "" {
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNum)
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+
+		// Arrays may span multiple lines
+		if strings.HasPrefix(value, "[") {
+			for !strings.HasSuffix(value, "]") && s.Scan() {
+				lineNum++
+				value += " " + stripComment(s.Text())
+			}
+		}
+
+		values, err := parseValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNum, err)
+		}
+		cfg[key] = values
+	}
+	return cfg, s.Err()
+}
+
+// parseValue parses a value or an array of values
+func parseValue(s string) ([]string, error) {
+	var values []string
+	array := strings.HasPrefix(s, "[")
+	if array {
+		s = s[1:]
+	}
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			if array {
+				return nil, fmt.Errorf("unterminated array")
+			}
+			return values, nil
+		}
+		if array && strings.HasPrefix(s, "]") {
+			if rest := strings.TrimSpace(s[1:]); rest != "" {
+				return nil, fmt.Errorf("unexpected %q after array", rest)
+			}
+			return values, nil
+		}
+		if !array && len(values) > 0 {
+			return nil, fmt.Errorf("unexpected %q after value", s)
+		}
+
+		var value string
+		switch s[0] {
+		case '"':
+			end := 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			var err error
+			if value, err = strconv.Unquote(s[:end+1]); err != nil {
+				return nil, fmt.Errorf("invalid string %s", s[:end+1])
+			}
+			s = s[end+1:]
+		case '\'':
+			end := strings.IndexByte(s[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			value, s = s[1:end+1], s[end+2:]
+		default:
+			end := strings.IndexAny(s, " \t,]")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		values = append(values, value)
+	}
+}
+
+// stripComment removes a trailing comment from a line
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(s[:i])
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// apply sets the flags in fs that weren't given on the command line to their
+// values in cfg. Array values are joined with commas. Settings that aren't
+// flags of fs are skipped, since they may belong to another subcommand.
+func (cfg config) apply(fs *flag.FlagSet) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for key, values := range cfg {
+		if set[key] || fs.Lookup(key) == nil {
+			continue
+		}
+		if err := fs.Set(key, strings.Join(values, ",")); err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// check returns an error if cfg has a setting other than test-flags that isn't a
+// flag of any subcommand. Settings are shared by the subcommands, so those of
+// another subcommand are allowed.
+func (cfg config) check() error {
+	known := settings()
+	for key := range cfg {
+		if !known[key] {
+			return fmt.Errorf("unknown setting %q", key)
+		}
+	}
+	return nil
+}
+
+// settings returns the names of the settings a configuration file may hold
+func settings() map[string]bool {
+	known := map[string]bool{"test-flags": true}
+	for name, cmd := range commands {
+		fs := newFlagSet(name)
+		cmd.main(fs)
+		fs.VisitAll(func(f *flag.Flag) { known[f.Name] = true })
+	}
+	return known
+}
+
+// loadConfig reads the configuration file at path
+func loadConfig(path string) (config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := parseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return cfg, nil
+}
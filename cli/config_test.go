@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  config
+		err   string
+	}{
+		{
+			name:  "scalars",
+			input: "parallel = 4\nselect-tests = false\nformat = \"json\"\n",
+			want:  config{"parallel": {"4"}, "select-tests": {"false"}, "format": {"json"}},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "# settings\n\ntimeout = \"30s\" # per mutation\n",
+			want:  config{"timeout": {"30s"}},
+		},
+		{
+			name:  "hash in strings",
+			input: "a = \"x#y\"\nb = 'z#w' # comment\n",
+			want:  config{"a": {"x#y"}, "b": {"z#w"}},
+		},
+		{
+			name:  "escapes in basic strings",
+			input: `a = "say \"hi\""` + "\n" + `b = "C:\\"` + "\n" + `c = "\\" # comment`,
+			want:  config{"a": {`say "hi"`}, "b": {`C:\`}, "c": {`\`}},
+		},
+		{
+			name:  "literal strings are not unescaped",
+			input: `a = 'C:\dir'`,
+			want:  config{"a": {`C:\dir`}},
+		},
+		{
+			name:  "array",
+			input: `categories = ["arithmetic", 'boolean',]`,
+			want:  config{"categories": {"arithmetic", "boolean"}},
+		},
+		{
+			name:  "empty array",
+			input: "tags = []",
+			want:  config{"tags": nil},
+		},
+		{
+			name:  "multi-line array",
+			input: "test-flags = [\n  \"-count=1\", # no cache\n  \"-short\",\n]\nparallel = 2\n",
+			want:  config{"test-flags": {"-count=1", "-short"}, "parallel": {"2"}},
+		},
+		{
+			name:  "bare values in arrays",
+			input: "a = [1, 2,3]",
+			want:  config{"a": {"1", "2", "3"}},
+		},
+		{
+			name:  "missing equals",
+			input: "parallel 4",
+			err:   "line 1: expected key = value",
+		},
+		{
+			name:  "unterminated string",
+			input: "a = 1\nb = \"x",
+			err:   "line 2: unterminated string",
+		},
+		{
+			name:  "unterminated escape",
+			input: `a = "x\"`,
+			err:   "line 1: unterminated string",
+		},
+		{
+			name:  "unterminated array",
+			input: "a = [1,\n2",
+			err:   "line 2: unterminated array",
+		},
+		{
+			name:  "text after value",
+			input: `a = "x" "y"`,
+			err:   `line 1: unexpected "\"y\"" after value`,
+		},
+		{
+			name:  "text after array",
+			input: "a = [1] 2",
+			err:   `line 1: unexpected "2" after array`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := parseConfig(strings.NewReader(test.input))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg, test.want) {
+				t.Errorf("got %q, want %q", cfg, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config
+		err  string
+	}{
+		{name: "shared", cfg: config{"categories": {"arithmetic"}, "test-flags": {"-short"}}},
+		// Settings of any subcommand are allowed, since they share the file
+		{name: "run", cfg: config{"db": {"mutation-history.jsonl"}, "min-score": {"80"}}},
+		{name: "watch", cfg: config{"interval": {"2s"}}},
+		{name: "worker", cfg: config{"connect": {"localhost:7777"}}},
+		{name: "unknown", cfg: config{"parallel": {"4"}, "paralel": {"4"}}, err: `unknown setting "paralel"`},
+	}
+	for _, test := range tests {
+		err := test.cfg.check()
+		if test.err == "" && err != nil {
+			t.Errorf("%s: got error %v", test.name, err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kisielk/mutator"
+)
+
+// defaultHistory is the history file read by the history subcommand if none is configured
+const defaultHistory = "mutation-history.jsonl"
+
+// historyMain runs the history subcommand, which shows how the mutation score
+// changed over the runs recorded with -db and the mutants that regressed from
+// killed to survived between two of them.
+func historyMain(fs *flag.FlagSet) func(args []string) {
+	db := fs.String("db", "",
+		"The history file to read. Defaults to the db setting of the configuration file, or "+defaultHistory+".")
+	from := fs.String("from", "", "The commit to compare against. Defaults to that of the second most recent run.")
+	to := fs.String("to", "", "The commit to compare. Defaults to that of the most recent run.")
+	return func(args []string) {
+		fs.Parse(args)
+
+		if *db == "" {
+			*db = defaultHistory
+			path, err := findConfig()
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			if path != "" {
+				cfg, err := loadConfig(path)
+				if err != nil {
+					Errf("could not read config: %s\n", err)
+				}
+				if err := cfg.check(); err != nil {
+					Errf("%s: %s\n", path, err)
+				}
+				if values := cfg["db"]; len(values) > 0 {
+					*db = values[0]
+				}
+			}
+		}
+
+		runs, err := (&mutator.History{Path: *db}).Load()
+		if err != nil {
+			Errf("could not read history: %s\n", err)
+		}
+		if len(runs) == 0 {
+			Errf("no runs recorded in %s\n", *db)
+		}
+		printTrend(os.Stdout, runs)
+
+		if len(runs) < 2 {
+			if *from != "" || *to != "" {
+				Errf("need at least two runs to compare\n")
+			}
+			return
+		}
+		fromRun, toRun := &runs[len(runs)-2], &runs[len(runs)-1]
+		if *from != "" {
+			if fromRun = findRun(runs, *from); fromRun == nil {
+				Errf("no run recorded for commit %s\n", *from)
+			}
+		}
+		if *to != "" {
+			if toRun = findRun(runs, *to); toRun == nil {
+				Errf("no run recorded for commit %s\n", *to)
+			}
+		}
+
+		regressed := mutator.Regressions(fromRun, toRun)
+		fmt.Printf("\n%d mutants regressed from killed to survived between %s and %s\n",
+			len(regressed), runName(fromRun), runName(toRun))
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, m := range regressed {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s -> %s\n", m.Package, m.ID, m.Category, m.Original, m.Replacement)
+		}
+		tw.Flush()
+	}
+}
+
+// printTrend writes a line for each run with its score and the change from the previous run
+func printTrend(w io.Writer, runs []mutator.RunRecord) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "TIME\tCOMMIT\tMUTANTS\tKILLED\tSURVIVED\tSCORE\tCHANGE\n")
+	var prev float64
+	for i := range runs {
+		s := runs[i].Summary()
+		score := 100 * s.Score()
+		change := ""
+		if i > 0 {
+			change = fmt.Sprintf("%+.1f", score-prev)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%.1f%%\t%s\n", runs[i].Time.Local().Format("2006-01-02 15:04"),
+			runName(&runs[i]), s.Total(), s[mutator.Killed], s[mutator.Survived], score, change)
+		prev = score
+	}
+	tw.Flush()
+}
+
+// runName returns the abbreviated commit of run, marked if the tree was dirty
+func runName(run *mutator.RunRecord) string {
+	name := run.Commit
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	if run.Dirty {
+		name += "+dirty"
+	}
+	return name
+}
+
+// findRun returns the most recent run of the commit rev, which may be any
+// revision git understands or a prefix of a recorded commit hash
+func findRun(runs []mutator.RunRecord, rev string) *mutator.RunRecord {
+	if out, err := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output(); err == nil {
+		rev = strings.TrimSpace(string(out))
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Commit != "" && strings.HasPrefix(runs[i].Commit, rev) {
+			return &runs[i]
+		}
+	}
+	return nil
+}
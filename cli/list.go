@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/kisielk/mutator"
+)
+
+// listMain runs the list subcommand, which lists the mutation sites of packages
+// without testing them
+func listMain(fs *flag.FlagSet) func(args []string) {
+	flags := addMutantFlags(fs)
+	return func(args []string) {
+		patterns, _, _ := flags.parse(fs, args)
+		if len(patterns) == 0 {
+			fs.Usage()
+			Errf("must provide a package\n")
+		}
+
+		r := &mutator.Runner{Options: flags.options(nil)}
+		pkgPaths, err := mutator.ExpandPackages(r.Build, patterns)
+		if err != nil {
+			Errf("%s\n", err)
+		}
+		for _, pkgPath := range pkgPaths {
+			if err := listPackage(os.Stdout, pkgPath, r); err != nil {
+				Errf("%s\n", err)
+			}
+		}
+	}
+}
+
+// listPackage writes every mutation site of the named package to w along with
+// its ID, category, the change it makes and the source line it appears on.
+func listPackage(w io.Writer, name string, r *mutator.Runner) error {
+	pkg, err := mutator.LoadPackage(r.Build, name)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, file := range pkg.Files {
+		srcFile := pkg.Fset.File(file.Pos()).Name()
+		src, err := ioutil.ReadFile(srcFile)
+		if err != nil {
+			return err
+		}
+		lines := bytes.Split(src, []byte("\n"))
+
+		mutants, _ := r.Mutants(pkg, file)
+		for _, m := range mutants {
+			pos := pkg.Fset.Position(m.Pos)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s -> %s\t%s\n", mutator.MutationID(pos), m.ID, m.Category,
+				m.Original, m.Replacement, bytes.TrimSpace(lines[pos.Line-1]))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/kisielk/mutator"
+)
+
+// formats lists the formats of the reports written to stdout
+const formats = "text, html, sarif, junit, json, github or markdown"
+
+// formatReporter returns a reporter collecting results for a report in the given
+// format, and a function writing the report once every result has been reported.
+// Both are nil for the text format, which is written as results arrive.
+func formatReporter(format string) (mutator.Reporter, func(io.Writer) error, error) {
+	switch format {
+	case "text":
+		return nil, nil, nil
+	case "html":
+		r := &mutator.HTMLReporter{}
+		return r, r.Write, nil
+	case "sarif":
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, nil, err
+		}
+		r := &mutator.SARIFReporter{BaseDir: wd}
+		return r, r.Write, nil
+	case "junit":
+		r := &mutator.JUnitReporter{}
+		return r, r.Write, nil
+	case "github":
+		r := &mutator.GitHubReporter{BaseDir: repoRoot()}
+		return r, r.Write, nil
+	case "markdown":
+		r := &mutator.MarkdownReporter{BaseDir: repoRoot()}
+		return r, r.Write, nil
+	case "json":
+		r := newRunRecord()
+		return r, func(w io.Writer) error {
+			r.Duration = time.Since(r.Time)
+			return writeJSON(w, r)
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown format %q", format)
+}
+
+// repoRoot returns the root of the git repository containing the current
+// directory, or the current directory if it isn't in one
+func repoRoot() string {
+	if out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+
+func writeJSON(w io.Writer, run *mutator.RunRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(run)
+}
+
+// reportMain runs the report subcommand, which renders the results of a run
+// stored by run -format json, or recorded in a history file with -db
+func reportMain(fs *flag.FlagSet) func(args []string) {
+	format := fs.String("format", "text", "The format of the report written to stdout: "+formats+".")
+	commit := fs.String("commit", "",
+		"The commit whose most recent run is reported when the file holds several runs. Defaults to the most recent run.")
+	badge := fs.String("badge", "", "Also write an SVG badge showing the mutation score to the given file, such as badge.svg.")
+	shields := fs.String("shields", "", "Also write the mutation score to the given file as JSON for a shields.io endpoint badge.")
+	return func(args []string) {
+		fs.Parse(args)
+		if fs.NArg() != 1 {
+			fs.Usage()
+			Errf("must provide a results file\n")
+		}
+
+		runs, err := (&mutator.History{Path: fs.Arg(0)}).Load()
+		if err != nil {
+			Errf("could not read results: %s\n", err)
+		}
+		if len(runs) == 0 {
+			Errf("no runs recorded in %s\n", fs.Arg(0))
+		}
+		run := &runs[len(runs)-1]
+		if *commit != "" {
+			if run = findRun(runs, *commit); run == nil {
+				Errf("no run recorded for commit %s\n", *commit)
+			}
+		}
+
+		if *badge != "" {
+			if err := writeReport(*badge, func(w io.Writer) error { return mutator.WriteBadge(w, run.Summary()) }); err != nil {
+				Errf("could not write badge: %s\n", err)
+			}
+		}
+		if *shields != "" {
+			if err := writeReport(*shields, func(w io.Writer) error { return mutator.WriteShieldsEndpoint(w, run.Summary()) }); err != nil {
+				Errf("could not write shields.io endpoint: %s\n", err)
+			}
+		}
+
+		switch *format {
+		case "text":
+			run.Replay(&mutator.TextReporter{W: os.Stdout})
+			run.Summary().Print(os.Stdout)
+			return
+		case "json":
+			err = writeJSON(os.Stdout, run)
+		default:
+			var report mutator.Reporter
+			var write func(io.Writer) error
+			if report, write, err = formatReporter(*format); err != nil {
+				Errf("%s\n", err)
+			}
+			run.Replay(report)
+			err = write(os.Stdout)
+		}
+		if err != nil {
+			Errf("could not write %s report: %s\n", *format, err)
+		}
+	}
+}
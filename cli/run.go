@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/kisielk/mutator"
+)
+
+// runMain runs the run subcommand, which tests the mutants of packages
+func runMain(fs *flag.FlagSet) func(args []string) {
+	return runCommand("run", fs)
+}
+
+// serveMain runs the serve subcommand, which tests the mutants of packages like
+// run but hands them out to workers connecting over the network
+func serveMain(fs *flag.FlagSet) func(args []string) {
+	return runCommand("serve", fs)
+}
+
+// runCommand runs the named subcommand, run or serve
+func runCommand(name string, fs *flag.FlagSet) func(args []string) {
+	serve := name == "serve"
+	flags := addMutantFlags(fs)
+	var runner *runnerFlags
+	var listen, token *string
+	// Workers only run the tests, so serve has no flags for fuzzing or benchmarks
+	fuzz, bench := new(string), new(string)
+	fuzzTime, benchThreshold := new(time.Duration), new(float64)
+	if serve {
+		listen = fs.String("listen", "127.0.0.1:7777",
+			"The address to accept connections from workers on. Use :7777 to accept workers on other hosts.")
+		token = addTokenFlag(fs)
+	} else {
+		runner = addRunnerFlags(fs)
+		fuzz = fs.String("fuzz", "",
+			"A regular expression selecting a fuzz test to run against each mutation that survives its tests, as with go test -fuzz.\n"+
+				"It must match at most one fuzz test in each package.")
+		fuzzTime = fs.Duration("fuzztime", 10*time.Second, "How long to fuzz each mutation for with -fuzz.")
+		bench = fs.String("bench", "",
+			"A regular expression selecting benchmarks to run against each mutation that survives its tests, as with go test -bench.\n"+
+				"Mutations are killed by benchmarks that fail, run for longer than -timeout, or are slower than for the original package by more than\n"+
+				"-bench-threshold. Use -parallel 1 so that mutations tested at once don't slow down each other's benchmarks.")
+		benchThreshold = fs.Float64("bench-threshold", 20,
+			"The percentage by which a benchmark must be slower for a mutation than for the original package to kill it.")
+	}
+	keepTmp := fs.Bool("keep-tmp", false, "Don't remove the temporary directory holding mutated sources.")
+	list := fs.Bool("list", false, "List the mutation sites without running any tests. Deprecated: use mutator list.")
+	run := fs.String("test.run", "",
+		"A regular expression selecting the tests to run, as with go test -run. Tests covering each mutation are chosen from among these.")
+	race := fs.Bool("race", false, "Run the tests with the race detector enabled.")
+	count := fs.Int("count", 0, "Run each test the given number of times, as with go test -count. Use 1 to bypass the test cache.")
+	short := fs.Bool("short", false, "Run the tests with -short to skip long-running tests.")
+	only := fs.String("only", "", "Only test the mutation with the given ID, or those at the given file:line:col, as printed in reports.")
+	selectTests := fs.Bool("select-tests", true,
+		"Run only the tests that cover each mutation, as determined from per-test coverage profiles.")
+	sampleRate := fs.Float64("sample", 1, "The fraction of mutations to randomly select for testing.")
+	maxMutants := fs.Int("max-mutants", 0, "The maximum number of randomly selected mutations to test per package.")
+	order := fs.Int("order", 1,
+		"The number of mutations combined into each mutant. Orders above 1 test randomly chosen higher-order mutants.")
+	seed := fs.Int64("seed", 0,
+		"The seed used to select mutations with -sample and -max-mutants and to combine them with -order. Defaults to a random seed.")
+	equivalent := fs.Bool("equivalent", false,
+		"Build the test binary of each mutation first, and don't test those compiling to the same binary as the original.")
+	cacheDir := fs.String("cache", "",
+		"A directory such as .mutator-cache in which to store outcomes, so unchanged mutations aren't tested again.")
+	format := fs.String("format", "text",
+		"The format of the report written to stdout once all packages have been tested: "+formats+".")
+	junitPath := fs.String("junit", "", "Write a JUnit XML report with a test case for each mutation to the given file.")
+	markdownPath := fs.String("markdown", "",
+		"Write a Markdown summary of the outcomes and surviving mutations to the given file, for example to post as a pull request comment.")
+	parallelUsage, defaultParallel := "The number of mutations to test at once.", 1
+	if serve {
+		parallelUsage, defaultParallel = "The maximum number of mutations handed out to workers at once.", 64
+	}
+	parallel := fs.Int("parallel", defaultParallel, parallelUsage)
+	timeout := fs.Duration("timeout", 0, "The maximum time the tests may run for each mutation, passed to go test -timeout.")
+	baselinePath := fs.String("baseline", "",
+		"The baseline file listing accepted mutants, which are reported as suppressed if they survive. Defaults to the nearest "+
+			baselineName+" in the current directory or its parents, if there is one.")
+	updateBaseline := fs.Bool("update-baseline", false,
+		"Add the mutations that survive to the baseline file and remove those that are killed, creating it at the root of the repository if needed.")
+	dbPath := fs.String("db", "", "Record the outcome of every mutation in the given history file, such as "+defaultHistory+", for mutator history.")
+	logs := addLogFlags(fs)
+	minScore := fs.Float64("min-score", 0, "Exit with a non-zero status if the mutation score is below this percentage.")
+
+	return func(args []string) {
+		patterns, testFlags, _ := flags.parse(fs, args)
+		if len(patterns) == 0 {
+			fs.Usage()
+			Errf("must provide a package\n")
+		}
+		if serve {
+			*token = requireToken(*token)
+		}
+
+		if _, err := regexp.Compile(*run); err != nil {
+			Errf("-test.run: %s\n", err)
+		}
+		if *count < 0 {
+			Errf("-count must not be negative\n")
+		}
+		if _, err := regexp.Compile(*fuzz); err != nil {
+			Errf("-fuzz: %s\n", err)
+		}
+		if *fuzz != "" && *fuzzTime <= 0 {
+			Errf("-fuzztime must be positive\n")
+		}
+		if _, err := regexp.Compile(*bench); err != nil {
+			Errf("-bench: %s\n", err)
+		}
+		if *benchThreshold < 0 {
+			Errf("-bench-threshold must not be negative\n")
+		}
+		var explicit []string
+		if *race {
+			explicit = append(explicit, "-race")
+		}
+		if *count > 0 {
+			explicit = append(explicit, "-count="+strconv.Itoa(*count))
+		}
+		if *short {
+			explicit = append(explicit, "-short")
+		}
+
+		opts := flags.options(append(explicit, testFlags...))
+		opts.TestRun = *run
+		opts.Fuzz, opts.FuzzTime = *fuzz, *fuzzTime
+		opts.Bench, opts.BenchThreshold = *bench, *benchThreshold
+		if runner != nil {
+			opts.Docker = runner.docker()
+		}
+		opts.Only = *only
+		opts.Equivalent = *equivalent
+		opts.KeepTmp = *keepTmp
+		opts.SelectTests = *selectTests
+		opts.Parallel = *parallel
+		opts.Timeout = *timeout
+		if *cacheDir != "" {
+			opts.Cache = &mutator.Cache{Dir: *cacheDir}
+		}
+		var err error
+		if *baselinePath == "" {
+			if *updateBaseline {
+				*baselinePath, err = findBaseline()
+			} else {
+				*baselinePath, err = findFile(baselineName)
+			}
+			if err != nil {
+				Errf("%s\n", err)
+			}
+		}
+		if *baselinePath != "" {
+			if opts.Baseline, err = mutator.LoadBaseline(*baselinePath); err != nil {
+				Errf("could not read baseline: %s\n", err)
+			}
+		}
+		if *sampleRate <= 0 || *sampleRate > 1 {
+			Errf("-sample must be greater than 0 and at most 1\n")
+		}
+		if *order < 1 {
+			Errf("-order must be at least 1\n")
+		}
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		if *order > 1 {
+			opts.Combiner = &mutator.Combiner{Order: *order, Seed: *seed}
+		}
+		if *sampleRate < 1 || *maxMutants > 0 {
+			opts.Sampler = &mutator.Sampler{Rate: *sampleRate, Max: *maxMutants, Seed: *seed}
+		}
+
+		pkgPaths, err := mutator.ExpandPackages(opts.Build, patterns)
+		if err != nil {
+			Errf("%s\n", err)
+		}
+
+		r := &mutator.Runner{Options: opts}
+		if *list {
+			for _, pkgPath := range pkgPaths {
+				if err := listPackage(os.Stdout, pkgPath, r); err != nil {
+					Errf("%s\n", err)
+				}
+			}
+			return
+		}
+
+		// Messages are written through the progress reporter so they don't mix with its status line
+		var logW io.Writer = os.Stderr
+		if logs.level() > mutator.LevelQuiet {
+			r.Progress = mutator.NewProgress(os.Stderr)
+			logW = r.Progress
+		}
+		r.Log = logs.logger(logW)
+		if r.Log.Enabled(mutator.LevelNormal) {
+			r.Reporter = &mutator.TextReporter{W: r.Log.W, Verbose: r.Log.Enabled(mutator.LevelVerbose)}
+		}
+
+		report, writeFormat, err := formatReporter(*format)
+		if err != nil {
+			Errf("%s\n", err)
+		}
+		if report != nil {
+			r.Reporter = appendReporter(r.Reporter, report)
+		}
+
+		var junit *mutator.JUnitReporter
+		if *junitPath != "" {
+			junit = &mutator.JUnitReporter{}
+			r.Reporter = appendReporter(r.Reporter, junit)
+		}
+
+		var markdown *mutator.MarkdownReporter
+		if *markdownPath != "" {
+			markdown = &mutator.MarkdownReporter{BaseDir: repoRoot()}
+			r.Reporter = appendReporter(r.Reporter, markdown)
+		}
+
+		if *updateBaseline {
+			r.Reporter = appendReporter(r.Reporter, opts.Baseline)
+		}
+
+		var record *mutator.RunRecord
+		if *dbPath != "" {
+			record = newRunRecord()
+			r.Reporter = appendReporter(r.Reporter, record)
+		}
+
+		// Sources are never modified in place, so stopping the tests that are
+		// running and removing the temporary directories is all the cleanup needed.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if serve {
+			l, err := net.Listen("tcp", *listen)
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			defer l.Close()
+			r.Server = &mutator.Server{Token: *token, Log: r.Log}
+			defer r.Server.Close()
+			go r.Server.Serve(l)
+			fmt.Fprintf(os.Stderr, "waiting for workers on %s\n", l.Addr())
+		}
+
+		summary := make(mutator.Summary)
+		for _, pkgPath := range pkgPaths {
+			s, err := r.Run(ctx, pkgPath)
+			summary.Add(s)
+			if errors.Is(err, context.Canceled) {
+				fmt.Fprintf(os.Stderr, "interrupted, partial results:\n")
+				summary.Print(os.Stderr)
+				printRandomization(opts)
+				os.Exit(exitInterrupted)
+			} else if err != nil {
+				Errf("%s\n", err)
+			}
+			fmt.Fprintf(os.Stderr, "%s:\n", pkgPath)
+			s.Print(os.Stderr)
+		}
+
+		if len(pkgPaths) > 1 {
+			fmt.Fprintf(os.Stderr, "total for %d packages:\n", len(pkgPaths))
+			summary.Print(os.Stderr)
+		}
+		printRandomization(opts)
+
+		if writeFormat != nil {
+			if err := writeFormat(os.Stdout); err != nil {
+				Errf("could not write %s report: %s\n", *format, err)
+			}
+		}
+
+		if junit != nil {
+			if err := writeReport(*junitPath, junit.Write); err != nil {
+				Errf("could not write JUnit report: %s\n", err)
+			}
+		}
+
+		if markdown != nil {
+			if err := writeReport(*markdownPath, markdown.Write); err != nil {
+				Errf("could not write Markdown summary: %s\n", err)
+			}
+		}
+
+		if *updateBaseline {
+			if err := opts.Baseline.Save(); err != nil {
+				Errf("could not write baseline: %s\n", err)
+			}
+		}
+
+		if record != nil {
+			record.Duration = time.Since(record.Time)
+			if err := (&mutator.History{Path: *dbPath}).Append(record); err != nil {
+				Errf("could not record run: %s\n", err)
+			}
+		}
+
+		// A run whose surviving mutants were all suppressed has no score to fall short
+		allSuppressed := summary[mutator.Killed]+summary[mutator.Survived] == 0 && summary[mutator.Suppressed] > 0
+		if score := 100 * summary.Score(); score < *minScore && !allSuppressed {
+			fmt.Fprintf(os.Stderr, "mutation score %.1f%% is below the minimum of %.1f%%\n", score, *minScore)
+			os.Exit(exitLowScore)
+		}
+	}
+}
+
+// printRandomization describes how mutants were randomly combined and sampled,
+// including the seed needed to repeat the run
+func printRandomization(opts mutator.Options) {
+	if opts.Combiner != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", opts.Combiner)
+	}
+	if opts.Sampler != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", opts.Sampler)
+	}
+}
+
+// newRunRecord returns a record of a run starting now at the current commit
+func newRunRecord() *mutator.RunRecord {
+	record := &mutator.RunRecord{Time: time.Now()}
+	record.Commit, record.Dirty = mutator.GitCommit()
+	return record
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/kisielk/mutator"
+)
+
+// showMain runs the show subcommand, which prints the diff of the mutant with a
+// given ID, or of the mutants at a given position. Several mutations are often
+// made at the same position, so every match is shown unless -index selects one
+// of them. The selected mutant can be written out and tested, which helps to
+// find out why it survived.
+func showMain(fs *flag.FlagSet) func(args []string) {
+	flags := addMutantFlags(fs)
+	index := fs.Int("index", 0, "The 1-based index of the mutant to show when several are at the given position. Defaults to all of them.")
+	write := fs.String("write", "", "Write the mutated source of the file to the given path, or to stdout if it's -.")
+	test := fs.Bool("test", false, "Run the tests verbosely against the mutant and print their output.")
+	runner := addRunnerFlags(fs)
+	keepTmp := fs.Bool("keep-tmp", false, "Don't remove the temporary directory holding the mutated source when testing it.")
+	return func(args []string) {
+		patterns, testFlags, _ := flags.parse(fs, args)
+		if len(patterns) == 0 {
+			fs.Usage()
+			Errf("must provide a mutation ID\n")
+		}
+		id, patterns := patterns[0], patterns[1:]
+		if len(patterns) == 0 {
+			patterns = []string{"."}
+		}
+
+		opts := flags.options(append([]string{"-v"}, testFlags...))
+		opts.Only = id
+		opts.KeepTmp = *keepTmp
+		opts.Docker = runner.docker()
+		r := &mutator.Runner{Options: opts, Log: &mutator.Logger{W: os.Stderr, Level: mutator.LevelNormal}}
+		pkgPaths, err := mutator.ExpandPackages(r.Build, patterns)
+		if err != nil {
+			Errf("%s\n", err)
+		}
+
+		type match struct {
+			pkg  *mutator.Package
+			file *ast.File
+			m    mutator.Mutant
+		}
+		var matches []match
+		for _, pkgPath := range pkgPaths {
+			pkg, err := mutator.LoadPackage(r.Build, pkgPath)
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			for _, file := range pkg.Files {
+				mutants, ignored := r.Mutants(pkg, file)
+				for _, m := range append(mutants, ignored...) {
+					matches = append(matches, match{pkg, file, m})
+				}
+			}
+		}
+		if len(matches) == 0 {
+			Errf("no mutation %s found\n", id)
+		}
+		if *index < 0 || *index > len(matches) {
+			Errf("-index must be between 1 and %d\n", len(matches))
+		}
+		if *index > 0 {
+			matches = matches[*index-1 : *index]
+		}
+		if (*write != "" || *test) && len(matches) > 1 {
+			Errf("%d mutations are at %s, use -index to select one\n", len(matches), id)
+		}
+
+		for i, match := range matches {
+			orig, err := match.pkg.Source(match.file, nil)
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			src, err := match.pkg.Source(match.file, &match.m)
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			// Diffs go to stderr when the mutated source is written to stdout
+			w := os.Stdout
+			if *write == "-" {
+				w = os.Stderr
+			}
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			name := match.pkg.Fset.File(match.file.Pos()).Name()
+			pos := match.pkg.Fset.Position(match.m.Pos)
+			fmt.Fprintf(w, "%s %s (%s) %s: %s -> %s\n", match.pkg.ImportPath, mutator.MutationID(pos), match.m.ID,
+				match.m.Category, match.m.Original, match.m.Replacement)
+			fmt.Fprint(w, mutator.Diff(filepath.Base(name), orig, src))
+
+			switch *write {
+			case "":
+			case "-":
+				os.Stdout.Write(src)
+			default:
+				if err := ioutil.WriteFile(*write, src, 0644); err != nil {
+					Errf("%s\n", err)
+				}
+			}
+		}
+
+		if *test {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			match := matches[0]
+			result, err := r.Test(ctx, match.pkg, match.file, match.m)
+			if err != nil {
+				Errf("%s\n", err)
+			}
+			os.Stderr.Write(result.Output)
+			fmt.Fprintf(os.Stderr, "mutation %s %s\n", result.Name(), result.Outcome)
+		}
+	}
+}
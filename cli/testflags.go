@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// goTestFlags are the flags of go test and the build flags it accepts, mapped to
+// whether they take a value
+var goTestFlags = map[string]bool{
+	// Test flags
+	"bench": true, "benchmem": false, "benchtime": true, "blockprofile": true, "blockprofilerate": true,
+	"count": true, "cover": false, "covermode": true, "coverpkg": true, "coverprofile": true, "cpu": true,
+	"cpuprofile": true, "failfast": false, "fullpath": false, "fuzz": true, "fuzzminimizetime": true,
+	"fuzztime": true, "memprofile": true, "memprofilerate": true, "mutexprofile": true,
+	"mutexprofilefraction": true, "outputdir": true, "parallel": true, "run": true, "short": false,
+	"shuffle": true, "skip": true, "timeout": true, "trace": true, "v": false, "vet": true,
+
+	// Build flags
+	"a": false, "asan": false, "asmflags": true, "buildmode": true, "buildvcs": true, "compiler": true,
+	"gccgoflags": true, "gcflags": true, "installsuffix": true, "ldflags": true, "linkshared": false,
+	"mod": true, "modcacherw": false, "modfile": true, "msan": false, "p": true, "pgo": true,
+	"pkgdir": true, "race": false, "toolexec": true, "trimpath": false, "work": false, "x": false,
+}
+
+// conflictingTestFlags are flags which would stop mutator from running the tests
+// or interpreting their results, with the reason why
+var conflictingTestFlags = map[string]string{
+	"c":       "the tests must be run",
+	"exec":    "the tests must be run by go test",
+	"json":    "mutator reads the plain output of go test",
+	"list":    "the tests must be run",
+	"n":       "the tests must be run",
+	"o":       "the tests must be run",
+	"overlay": "mutator substitutes mutated files with its own overlay",
+	"tags":    "use the -tags flag of mutator, which also selects the files to mutate",
+}
+
+// checkTestFlags returns an error if flags, which are passed to go test, contain
+// anything other than flags go test accepts, or flags that mutator has to control
+func checkTestFlags(flags []string) error {
+	for i := 0; i < len(flags); i++ {
+		arg := flags[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			return fmt.Errorf("unexpected argument %q among the test flags; packages must come before them", arg)
+		}
+		name := strings.TrimLeft(arg, "-")
+		hasValue := strings.Contains(name, "=")
+		name = strings.SplitN(name, "=", 2)[0]
+		name = strings.TrimPrefix(name, "test.")
+
+		if reason, ok := conflictingTestFlags[name]; ok {
+			return fmt.Errorf("test flag %s can't be used: %s", arg, reason)
+		}
+		takesValue, ok := goTestFlags[name]
+		if !ok {
+			return fmt.Errorf("unknown test flag %s; pass it after -- to skip this check", arg)
+		}
+		if takesValue && !hasValue {
+			if i+1 == len(flags) {
+				return fmt.Errorf("test flag %s needs a value", arg)
+			}
+			i++
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import "testing"
+
+func TestCheckTestFlags(t *testing.T) {
+	tests := []struct {
+		flags []string
+		err   string
+	}{
+		{flags: nil},
+		{flags: []string{"-v", "-short"}},
+		{flags: []string{"-run", "TestA", "-count=1"}},
+		{flags: []string{"--run=TestA", "-test.count", "2"}},
+		{flags: []string{"-race", "-gcflags", "-N -l"}},
+		{flags: []string{"-test.v"}},
+		{
+			flags: []string{"-v", "./..."},
+			err:   `unexpected argument "./..." among the test flags; packages must come before them`,
+		},
+		{
+			flags: []string{"-"},
+			err:   `unexpected argument "-" among the test flags; packages must come before them`,
+		},
+		{
+			flags: []string{"-json"},
+			err:   "test flag -json can't be used: mutator reads the plain output of go test",
+		},
+		{
+			flags: []string{"-tags=foo"},
+			err:   "test flag -tags=foo can't be used: use the -tags flag of mutator, which also selects the files to mutate",
+		},
+		{
+			flags: []string{"-test.list", "."},
+			err:   "test flag -test.list can't be used: the tests must be run",
+		},
+		{
+			flags: []string{"-myflag"},
+			err:   "unknown test flag -myflag; pass it after -- to skip this check",
+		},
+		{
+			flags: []string{"-v", "-timeout"},
+			err:   "test flag -timeout needs a value",
+		},
+		{
+			flags: []string{"-run", "-v", "extra"},
+			err:   `unexpected argument "extra" among the test flags; packages must come before them`,
+		},
+	}
+
+	for _, test := range tests {
+		err := checkTestFlags(test.flags)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("checkTestFlags(%q) = %v, want nil", test.flags, err)
+		case test.err != "" && (err == nil || err.Error() != test.err):
+			t.Errorf("checkTestFlags(%q) = %v, want %q", test.flags, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/kisielk/mutator"
+)
+
+// tuiMain runs the tui subcommand, which lists the surviving and suppressed
+// mutants of a run stored by run -format json or -db, and lets them be inspected,
+// tested again or accepted into the baseline one at a time
+func tuiMain(fs *flag.FlagSet) func(args []string) {
+	flags := addMutantFlags(fs)
+	commit := fs.String("commit", "",
+		"The commit whose most recent run is triaged when the file holds several runs. Defaults to the most recent run.")
+	baselinePath := fs.String("baseline", "",
+		"The baseline file accepted mutants are added to. Defaults to the nearest "+baselineName+
+			" in the current directory or its parents, or a new one at the root of the repository.")
+	runner := addRunnerFlags(fs)
+	return func(args []string) {
+		patterns, testFlags, _ := flags.parse(fs, args)
+		if len(patterns) != 1 {
+			fs.Usage()
+			Errf("must provide a results file\n")
+		}
+
+		runs, err := (&mutator.History{Path: patterns[0]}).Load()
+		if err != nil {
+			Errf("could not read results: %s\n", err)
+		}
+		if len(runs) == 0 {
+			Errf("no runs recorded in %s\n", patterns[0])
+		}
+		run := &runs[len(runs)-1]
+		if *commit != "" {
+			if run = findRun(runs, *commit); run == nil {
+				Errf("no run recorded for commit %s\n", *commit)
+			}
+		}
+
+		if *baselinePath == "" {
+			if *baselinePath, err = findBaseline(); err != nil {
+				Errf("%s\n", err)
+			}
+		}
+		baseline, err := mutator.LoadBaseline(*baselinePath)
+		if err != nil {
+			Errf("could not read baseline: %s\n", err)
+		}
+
+		t := &triage{baseline: baseline}
+		for _, m := range run.Mutants {
+			if m.Outcome == mutator.Survived || m.Outcome == mutator.Suppressed {
+				t.survivors = append(t.survivors, m)
+			}
+		}
+		if len(t.survivors) == 0 {
+			fmt.Println("no mutants survived")
+			return
+		}
+		sort.SliceStable(t.survivors, func(i, j int) bool {
+			a, b := t.survivors[i], t.survivors[j]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+
+		opts := flags.options(append([]string{"-v"}, testFlags...))
+		opts.Docker = runner.docker()
+		t.runner = &mutator.Runner{Options: opts}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		t.term = rawTerminal()
+		defer t.term.restore()
+		t.loop(ctx)
+	}
+}
+
+// triage is the state of the tui subcommand
+type triage struct {
+	survivors []mutator.MutantRecord
+	baseline  *mutator.Baseline
+	runner    *mutator.Runner
+	term      *terminal
+
+	// cursor is the index of the selected survivor, which is shown in detail if detail is set
+	cursor int
+	detail bool
+
+	// status is a message shown below the list, and output is the test output of
+	// the last time the selected survivor was tested again
+	status string
+	output []byte
+}
+
+// loop reads keys and acts on them until q is pressed or ctx is done
+func (t *triage) loop(ctx context.Context) {
+	for {
+		t.draw(os.Stdout)
+		key, err := t.nextKey(ctx)
+		if err != nil {
+			return
+		}
+
+		t.status = ""
+		m := &t.survivors[t.cursor]
+		switch key {
+		case "q":
+			return
+		case "j", "down":
+			t.move(1)
+		case "k", "up":
+			t.move(-1)
+		case "enter", "d":
+			t.detail = !t.detail
+		case "e":
+			if err := t.edit(m); err != nil {
+				t.status = err.Error()
+			}
+		case "a":
+			result := m.Result()
+			if t.baseline.Contains(result) {
+				t.baseline.Remove(result)
+				t.status = "removed " + m.ID + " from " + t.baseline.Path
+			} else {
+				t.baseline.Add(result)
+				t.status = "accepted " + m.ID + " in " + t.baseline.Path
+			}
+			if err := t.baseline.Save(); err != nil {
+				t.status = fmt.Sprintf("could not write baseline: %s", err)
+			}
+		case "r":
+			fmt.Printf("testing %s...\n", m.ID)
+			if err := t.rerun(ctx, m); err != nil {
+				t.status = err.Error()
+			} else {
+				t.status = fmt.Sprintf("mutation %s %s", m.ID, m.Outcome)
+			}
+		}
+	}
+}
+
+// nextKey returns the next key pressed, or the error of ctx if it's done first.
+// A key is only read while waiting for one, so that none are taken from the
+// editor sharing the terminal.
+func (t *triage) nextKey(ctx context.Context) (string, error) {
+	type read struct {
+		key string
+		err error
+	}
+	c := make(chan read, 1)
+	go func() {
+		key, err := t.term.readKey()
+		c <- read{key, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-c:
+		return r.key, r.err
+	}
+}
+
+// move moves the cursor by delta survivors, dropping the output of the last one
+func (t *triage) move(delta int) {
+	cursor := t.cursor + delta
+	if cursor < 0 || cursor >= len(t.survivors) {
+		return
+	}
+	t.cursor = cursor
+	t.output = nil
+}
+
+// draw writes the list of survivors grouped by file, or the selected one in detail
+func (t *triage) draw(w io.Writer) {
+	if t.term.raw {
+		// Move to the top left and clear the screen
+		fmt.Fprint(w, "\x1b[H\x1b[2J")
+	} else {
+		fmt.Fprintln(w)
+	}
+
+	if t.detail {
+		m := t.survivors[t.cursor]
+		fmt.Fprintf(w, "%s %s %s: %s -> %s\n", m.Package, m.ID, m.Category, m.Original, m.Replacement)
+		fmt.Fprintf(w, "%s\n\n", t.describe(m))
+		if m.Diff != "" {
+			fmt.Fprint(w, m.Diff)
+		} else {
+			fmt.Fprintln(w, "no diff was recorded, run with -format json to record it")
+		}
+		if t.output != nil {
+			fmt.Fprintf(w, "\n%s", t.output)
+		}
+	} else {
+		t.drawList(w, t.term.height()-3)
+	}
+
+	fmt.Fprintln(w)
+	if t.status != "" {
+		fmt.Fprintln(w, t.status)
+	}
+	fmt.Fprint(w, "j/k move  enter diff  e edit  a accept  r test again  q quit")
+	if !t.term.raw {
+		fmt.Fprint(w, " (then enter)")
+	}
+	fmt.Fprint(w, "\n")
+}
+
+// drawList writes the survivors around the cursor to w, in at most height lines
+func (t *triage) drawList(w io.Writer, height int) {
+	var lines []string
+	selected := 0
+	file := ""
+	for i, m := range t.survivors {
+		if m.File != file {
+			file = m.File
+			lines = append(lines, displayPath(file))
+		}
+		mark := " "
+		if i == t.cursor {
+			mark = ">"
+			selected = len(lines)
+		}
+		lines = append(lines, fmt.Sprintf("%s %5d:%-3d %-20s %s -> %s  %s",
+			mark, m.Line, m.Column, m.Category, m.Original, m.Replacement, t.describe(m)))
+	}
+
+	if height < 1 {
+		height = 1
+	}
+	start := 0
+	if selected >= height {
+		start = selected - height + 1
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for _, line := range lines[start:end] {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(w, "\n%d of %d survivors", t.cursor+1, len(t.survivors))
+}
+
+// describe returns the outcome of m if it was killed when tested again, and
+// whether it's accepted
+func (t *triage) describe(m mutator.MutantRecord) string {
+	var tags []string
+	if m.Outcome != mutator.Survived && m.Outcome != mutator.Suppressed {
+		tags = append(tags, m.Outcome.String())
+	}
+	if t.baseline.Contains(m.Result()) {
+		tags = append(tags, "accepted")
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(tags, ", ") + "]"
+}
+
+// edit opens the location of m in the editor named by $VISUAL or $EDITOR
+func (t *triage) edit(m *mutator.MutantRecord) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	args := append(strings.Fields(editor), "+"+strconv.Itoa(m.Line), m.File)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	t.term.restore()
+	defer t.term.makeRaw()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not run %s: %s", args[0], err)
+	}
+	return nil
+}
+
+// rerun tests m again against the current source of its package, updating its outcome
+func (t *triage) rerun(ctx context.Context, m *mutator.MutantRecord) error {
+	pkg, err := mutator.LoadPackage(t.runner.Build, m.Package)
+	if err != nil {
+		return err
+	}
+	t.runner.Only = m.ID
+	for _, file := range pkg.Files {
+		if pkg.Fset.File(file.Pos()).Name() != m.File {
+			continue
+		}
+		mutants, ignored := t.runner.Mutants(pkg, file)
+		for _, mutant := range append(mutants, ignored...) {
+			if mutant.Category != m.Category || mutant.Original != m.Original || mutant.Replacement != m.Replacement {
+				continue
+			}
+			result, err := t.runner.Test(ctx, pkg, file, mutant)
+			if err != nil {
+				return err
+			}
+			m.Outcome, t.output = result.Outcome, result.Output
+			return nil
+		}
+	}
+	return fmt.Errorf("mutation %s was not found, the code may have changed", m.ID)
+}
+
+// displayPath returns path relative to the current directory if it's within it
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// terminal reads keys from stdin. If stdin is a terminal it's switched with stty
+// to passing single key presses without echoing them; otherwise a line is read
+// for each key.
+type terminal struct {
+	in *bufio.Reader
+
+	// raw reports whether the terminal was switched, and saved holds its
+	// previous settings
+	raw   bool
+	saved string
+}
+
+func rawTerminal() *terminal {
+	t := &terminal{in: bufio.NewReader(os.Stdin)}
+	if saved, err := stty("-g"); err == nil {
+		t.saved = strings.TrimSpace(saved)
+		t.makeRaw()
+	}
+	return t
+}
+
+// makeRaw switches the terminal to passing single key presses, if it can be
+func (t *terminal) makeRaw() {
+	if t.saved != "" {
+		_, err := stty("-icanon", "-echo", "min", "1")
+		t.raw = err == nil
+	}
+}
+
+// restore switches the terminal back to its previous settings
+func (t *terminal) restore() {
+	if t.raw {
+		stty(t.saved)
+		t.raw = false
+	}
+}
+
+// height returns the number of lines of the terminal
+func (t *terminal) height() int {
+	if t.raw {
+		if size, err := stty("size"); err == nil {
+			if fields := strings.Fields(size); len(fields) == 2 {
+				if rows, err := strconv.Atoi(fields[0]); err == nil && rows > 0 {
+					return rows
+				}
+			}
+		}
+	}
+	return 24
+}
+
+// readKey returns the next key pressed: a single character, or enter, up or down
+func (t *terminal) readKey() (string, error) {
+	if !t.raw {
+		line, err := t.in.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return "enter", nil
+		}
+		return line[:1], nil
+	}
+
+	c, err := t.in.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 0x1b:
+		// Arrow keys are sent as ESC [ A and ESC [ B
+		if next, err := t.in.ReadByte(); err != nil || next != '[' {
+			return "", err
+		}
+		switch c, err := t.in.ReadByte(); {
+		case err != nil:
+			return "", err
+		case c == 'A':
+			return "up", nil
+		case c == 'B':
+			return "down", nil
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
+
+// stty runs stty with the given arguments on the terminal of stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kisielk/mutator"
+)
+
+// watchMain runs the watch subcommand, which tests the mutants of a package and
+// then tests those of each function again whenever it changes, printing the
+// mutants that started or stopped surviving
+func watchMain(fs *flag.FlagSet) func(args []string) {
+	flags := addMutantFlags(fs)
+	interval := fs.Duration("interval", time.Second, "How often to check the package's files for changes.")
+	parallel := fs.Int("parallel", 1, "The number of mutations to test at once.")
+	timeout := fs.Duration("timeout", 0, "The maximum time the tests may run for each mutation, passed to go test -timeout.")
+	selectTests := fs.Bool("select-tests", true,
+		"Run only the tests that cover each mutation, as determined from per-test coverage profiles.")
+	cacheDir := fs.String("cache", "",
+		"A directory such as .mutator-cache in which to store outcomes, so unchanged mutations aren't tested again.")
+	logs := addLogFlags(fs)
+	return func(args []string) {
+		patterns, testFlags, _ := flags.parse(fs, args)
+		if len(patterns) != 1 {
+			fs.Usage()
+			Errf("must provide a single package\n")
+		}
+		if *interval <= 0 {
+			Errf("-interval must be positive\n")
+		}
+
+		opts := flags.options(testFlags)
+		opts.Parallel = *parallel
+		opts.Timeout = *timeout
+		opts.SelectTests = *selectTests
+		if *cacheDir != "" {
+			opts.Cache = &mutator.Cache{Dir: *cacheDir}
+		}
+		pkgPaths, err := mutator.ExpandPackages(opts.Build, patterns)
+		if err != nil {
+			Errf("%s\n", err)
+		}
+		if len(pkgPaths) != 1 {
+			Errf("%s matches %d packages, watch needs a single package\n", patterns[0], len(pkgPaths))
+		}
+
+		r := &mutator.Runner{Options: opts}
+		var logW io.Writer = os.Stderr
+		if logs.level() > mutator.LevelQuiet {
+			r.Progress = mutator.NewProgress(os.Stderr)
+			logW = r.Progress
+		}
+		r.Log = logs.logger(logW)
+		if r.Log.Enabled(mutator.LevelVerbose) {
+			r.Reporter = &mutator.TextReporter{W: r.Log.W, Verbose: true}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		first := true
+		err = r.Watch(ctx, pkgPaths[0], *interval, func(delta mutator.WatchDelta) {
+			if first {
+				fmt.Fprintf(os.Stderr, "tested %s, watching for changes\n", pkgPaths[0])
+			} else {
+				fmt.Fprintf(os.Stderr, "tested %s\n", strings.Join(delta.Funcs, ", "))
+			}
+			printDelta("+ survived", delta.Survived)
+			printDelta("- killed  ", delta.Killed)
+			first = false
+			delta.Summary.Print(os.Stderr)
+		})
+		if err != nil {
+			Errf("%s\n", err)
+		}
+	}
+}
+
+// printDelta describes each result on its own line, after prefix
+func printDelta(prefix string, results []mutator.Result) {
+	for _, result := range results {
+		m := result.Mutant
+		fmt.Fprintf(os.Stderr, "%s %s %s: %s -> %s\n", prefix, result.Name(), m.Category, m.Original, m.Replacement)
+	}
+}
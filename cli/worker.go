@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kisielk/mutator"
+)
+
+// workerMain runs the worker subcommand, which tests the mutants handed out by
+// mutator serve. It must be run in a checkout of the same revision as the server,
+// from where the packages' import paths resolve the same way.
+func workerMain(fs *flag.FlagSet) func(args []string) {
+	connect := fs.String("connect", "", "The host:port address of the mutator serve coordinator.")
+	token := addTokenFlag(fs)
+	parallel := fs.Int("parallel", 1, "The number of mutations to test at once.")
+	logs := addLogFlags(fs)
+	runner := addRunnerFlags(fs)
+	return func(args []string) {
+		fs.Parse(args)
+		if *connect == "" || fs.NArg() > 0 {
+			fs.Usage()
+			Errf("must provide the address to connect to with -connect\n")
+		}
+
+		w := &mutator.Worker{Token: requireToken(*token), Parallel: *parallel, Docker: runner.docker(), Log: logs.logger(os.Stderr)}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := w.Run(ctx, *connect); errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "interrupted\n")
+			os.Exit(exitInterrupted)
+		} else if err != nil {
+			Errf("%s\n", err)
+		}
+	}
+}
+
+// tokenEnv is the environment variable holding the token if -token isn't given
+const tokenEnv = "MUTATOR_TOKEN"
+
+// addTokenFlag adds the -token flag holding the secret shared by mutator serve and its workers
+func addTokenFlag(fs *flag.FlagSet) *string {
+	return fs.String("token", "",
+		"The secret shared by mutator serve and its workers, which are only handed mutations if they present it. Defaults to $"+tokenEnv+".")
+}
+
+// requireToken returns token, or the one in the environment if it's empty, and
+// exits if neither is set
+func requireToken(token string) string {
+	if token == "" {
+		token = os.Getenv(tokenEnv)
+	}
+	if token == "" {
+		Errf("must provide the token shared by mutator serve and its workers with -token or $%s\n", tokenEnv)
+	}
+	return token
+}
@@ -0,0 +1,8 @@
+// Command mutator runs mutation tests on Go packages.
+package main
+
+import "github.com/kisielk/mutator/cli"
+
+func main() {
+	cli.Main()
+}
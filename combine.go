@@ -0,0 +1,114 @@
+package mutator
+
+import (
+	"fmt"
+	"go/token"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Combiner builds higher-order mutants, each of which applies several mutations
+// at different positions in a file at once. They are harder to kill than the
+// mutants they're made of, which makes them useful for stress-testing suites
+// that already kill every first-order mutant.
+type Combiner struct {
+	// Order is the number of mutations combined into each mutant
+	Order int
+
+	// Seed is the seed of the random number generator, which makes runs reproducible
+	Seed int64
+
+	rng *rand.Rand
+}
+
+func (c *Combiner) String() string {
+	return fmt.Sprintf("combined %d mutations per mutant with -seed %d", c.Order, c.Seed)
+}
+
+// Combine returns higher-order mutants for a package, whose mutations are grouped
+// by file. Rather than building every combination, the mutations of each file are
+// shuffled and split into groups, so each is used exactly once. The last group in
+// a file may be smaller than Order, and a mutation left on its own is dropped.
+func (c *Combiner) Combine(files [][]Mutant) [][]Mutant {
+	if c.rng == nil {
+		c.rng = rand.New(rand.NewSource(c.Seed))
+	}
+
+	combined := make([][]Mutant, len(files))
+	for i, mutants := range files {
+		shuffled := append([]Mutant{}, mutants...)
+		c.rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		// Mutations at the same position replace the same node, so they can't be combined
+		var groups [][]Mutant
+	next:
+		for _, m := range shuffled {
+			for g, group := range groups {
+				if len(group) < c.Order && !hasPos(group, m) {
+					groups[g] = append(group, m)
+					continue next
+				}
+			}
+			groups = append(groups, []Mutant{m})
+		}
+
+		for _, group := range groups {
+			if len(group) > 1 {
+				combined[i] = append(combined[i], combineMutants(group))
+			}
+		}
+		sort.SliceStable(combined[i], func(a, b int) bool {
+			return combined[i][a].Pos < combined[i][b].Pos
+		})
+	}
+	return combined
+}
+
+func hasPos(mutants []Mutant, m Mutant) bool {
+	for _, other := range mutants {
+		if other.Pos == m.Pos {
+			return true
+		}
+	}
+	return false
+}
+
+// combineMutants returns a mutant applying all of mutants, positioned at the first of them
+func combineMutants(mutants []Mutant) Mutant {
+	sort.Slice(mutants, func(i, j int) bool { return mutants[i].Pos < mutants[j].Pos })
+
+	var categories, originals, replacements, ids []string
+	var positions []token.Pos
+	seen := make(map[string]bool)
+	for _, m := range mutants {
+		positions = append(positions, m.Pos)
+		if !seen[m.Category] {
+			seen[m.Category] = true
+			categories = append(categories, m.Category)
+		}
+		originals = append(originals, m.Original)
+		replacements = append(replacements, m.Replacement)
+		ids = append(ids, m.ID)
+	}
+
+	return Mutant{
+		Pos:         mutants[0].Pos,
+		Category:    strings.Join(categories, "+"),
+		Original:    strings.Join(originals, "; "),
+		Replacement: strings.Join(replacements, "; "),
+		ID:          strings.Join(ids, "+"),
+		positions:   positions,
+		Apply: func() {
+			for _, m := range mutants {
+				m.Apply()
+			}
+		},
+		// Mutations are reverted in the opposite order in case they touch the same part of the tree
+		Revert: func() {
+			for i := len(mutants) - 1; i >= 0; i-- {
+				mutants[i].Revert()
+			}
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package mutator
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestCombineSkipsSamePosition(t *testing.T) {
+	// Several mutants replace the same node: | has three replacements and each
+	// literal two or three
+	_, file, info := checkSource(t, `package p
+
+func F(a, b int) int {
+	return a | b + 2*3 | 4
+}
+`)
+	mutants := FindMutants(file, info, map[string]bool{"binary": true, "literal": true, "arithmetic": true})
+	for seed := int64(0); seed < 50; seed++ {
+		c := &Combiner{Order: 3, Seed: seed}
+		for _, m := range c.Combine([][]Mutant{mutants})[0] {
+			seen := make(map[token.Pos]bool)
+			for _, pos := range m.positions {
+				if seen[pos] {
+					t.Fatalf("seed %d: combined mutant %s -> %s has two mutations at the same position", seed, m.Original, m.Replacement)
+				}
+				seen[pos] = true
+			}
+		}
+	}
+}
+
+func TestCombineUsesEachMutantOnce(t *testing.T) {
+	files := testFiles(7, 1, 4)
+	c := &Combiner{Order: 2, Seed: 1}
+	combined := c.Combine(files)
+
+	want := []int{3, 0, 2}
+	for i, mutants := range combined {
+		if len(mutants) != want[i] {
+			t.Errorf("file %d: got %d combined mutants, want %d", i, len(mutants), want[i])
+		}
+		seen := make(map[token.Pos]bool)
+		for _, m := range mutants {
+			if len(m.positions) < 2 || len(m.positions) > c.Order {
+				t.Errorf("file %d: combined mutant of %d mutations", i, len(m.positions))
+			}
+			for _, pos := range m.positions {
+				if seen[pos] {
+					t.Errorf("file %d: mutant at %d combined twice", i, pos)
+				}
+				seen[pos] = true
+			}
+		}
+	}
+
+	again := (&Combiner{Order: 2, Seed: 1}).Combine(files)
+	for i := range combined {
+		if got, want := combinedPositions(again[i]), combinedPositions(combined[i]); !reflect.DeepEqual(got, want) {
+			t.Errorf("file %d: combinations with the same seed differ: %v and %v", i, got, want)
+		}
+	}
+}
+
+// combinedPositions returns the positions of the mutations applied by each mutant
+func combinedPositions(mutants []Mutant) [][]token.Pos {
+	var out [][]token.Pos
+	for _, m := range mutants {
+		out = append(out, m.positions)
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// ConditionVisitor finds if, for and switch case conditions that can be negated
+type ConditionVisitor struct {
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+}
+
+func (v *ConditionVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.IfStmt:
+		v.negate(&n.Cond)
+	case *ast.ForStmt:
+		if n.Cond != nil {
+			v.negate(&n.Cond)
+		}
+	case *ast.SwitchStmt:
+		// Only the cases of a switch without a tag are boolean conditions
+		if n.Tag != nil {
+			break
+		}
+		for _, stmt := range n.Body.List {
+			clause := stmt.(*ast.CaseClause)
+			for i := range clause.List {
+				v.negate(&clause.List[i])
+			}
+		}
+	}
+	return v
+}
+
+// negate records a mutation that negates the condition pointed to by cond,
+// or removes the negation if it is already negated
+func (v *ConditionVisitor) negate(cond *ast.Expr) {
+	orig := *cond
+	var repl ast.Expr
+	if exp, ok := orig.(*ast.UnaryExpr); ok && exp.Op == token.NOT {
+		repl = exp.X
+	} else {
+		x := orig
+		if _, ok := x.(*ast.BinaryExpr); ok {
+			x = &ast.ParenExpr{X: x}
+		}
+		repl = &ast.UnaryExpr{OpPos: orig.Pos(), Op: token.NOT, X: x}
+	}
+
+	v.Mutants = append(v.Mutants, Mutant{
+		Pos:         orig.Pos(),
+		Category:    "negate-conditionals",
+		Original:    types.ExprString(orig),
+		Replacement: types.ExprString(repl),
+		Apply:       func() { *cond = repl },
+		Revert:      func() { *cond = orig },
+	})
+}
+
+func conditionMutants(file *ast.File, info *types.Info) []Mutant {
+	var v ConditionVisitor
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -0,0 +1,130 @@
+package mutator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TestCoverage maps lines in the files of a package to the tests that execute
+// them. Lines in blocks that no test executes map to no tests, and lines outside
+// of every block, such as package-level declarations, aren't in the map.
+type TestCoverage map[string]map[int][]string
+
+// RunPattern returns a pattern for go test -run that selects the tests covering
+// any of the lines at positions. If no test covers them, the pattern matches no
+// tests so that the package is still built. If one of the lines isn't in any
+// block of the coverage profiles, which only cover function bodies, it's "" and
+// every test must be run.
+func (c TestCoverage) RunPattern(positions ...token.Position) string {
+	var tests []string
+	seen := make(map[string]bool)
+	for _, pos := range positions {
+		lineTests, ok := c[filepath.Base(pos.Filename)][pos.Line]
+		if !ok {
+			return ""
+		}
+		for _, test := range lineTests {
+			if !seen[test] {
+				seen[test] = true
+				tests = append(tests, test)
+			}
+		}
+	}
+	if len(tests) == 0 {
+		return "^$"
+	}
+	return "^(" + strings.Join(tests, "|") + ")$"
+}
+
+// BuildTestCoverage runs each test of pkg on its own with a coverage profile to
+// find the lines it executes. Profiles are written to tmpDir. If run is non-empty
+// only the tests it matches, as with go test -run, are considered.
+func BuildTestCoverage(ctx context.Context, pkg *Package, tmpDir, run string, testFlags []string) (TestCoverage, error) {
+	if run == "" {
+		run = "."
+	}
+	cmd := pkg.Build.Command(ctx, "test", "-list", run)
+	cmd.Dir = pkg.Dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list tests: %s", err)
+	}
+
+	cov := make(TestCoverage)
+	for _, test := range strings.Fields(string(output)) {
+		if !strings.HasPrefix(test, "Test") && !strings.HasPrefix(test, "Example") && !strings.HasPrefix(test, "Fuzz") {
+			continue
+		}
+
+		profile := filepath.Join(tmpDir, "cover.out")
+		args := []string{"test", "-run", "^" + test + "$", "-coverprofile=" + profile}
+		args = append(args, testFlags...)
+		cmd := pkg.Build.Command(ctx, args...)
+		cmd.Dir = pkg.Dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("could not run %s with coverage: %s\n%s", test, err, output)
+		}
+		if err := cov.addProfile(profile, test); err != nil {
+			return nil, err
+		}
+	}
+	return cov, nil
+}
+
+// addProfile records test as covering every line executed according to the coverage profile at path
+func (c TestCoverage) addProfile(path, test string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Blocks are of the form name.go:line.column,line.column statements count
+		line := s.Text()
+		if strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		colon := strings.LastIndex(line, ":")
+		if len(fields) != 3 || colon < 0 {
+			return fmt.Errorf("malformed coverage profile line: %s", line)
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(fields[0][colon+1:], "%d.%d,%d", &start, new(int), &end); err != nil {
+			return fmt.Errorf("malformed coverage profile line: %s", line)
+		}
+		name := filepath.Base(line[:colon])
+		if c[name] == nil {
+			c[name] = make(map[int][]string)
+		}
+		for i := start; i <= end; i++ {
+			tests := c[name][i]
+			switch {
+			case fields[2] == "0":
+				// The line is recorded as in a block even if it isn't executed
+				c[name][i] = tests
+			case len(tests) == 0 || tests[len(tests)-1] != test:
+				c[name][i] = append(tests, test)
+			}
+		}
+	}
+	return s.Err()
+}
+
+// hasRunFlag reports whether flags already select the tests to run
+func hasRunFlag(flags []string) bool {
+	for _, f := range flags {
+		name := strings.SplitN(strings.TrimLeft(f, "-"), "=", 2)[0]
+		if name == "run" || name == "test.run" {
+			return true
+		}
+	}
+	return false
+}
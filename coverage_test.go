@@ -0,0 +1,60 @@
+package mutator
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestHasRunFlag(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  bool
+	}{
+		{flags: nil, want: false},
+		{flags: []string{"-v", "-count=1"}, want: false},
+		{flags: []string{"-run", "TestA"}, want: true},
+		{flags: []string{"-run=TestA"}, want: true},
+		{flags: []string{"--run=TestA"}, want: true},
+		{flags: []string{"-test.run", "TestA"}, want: true},
+		{flags: []string{"-v", "-test.run=TestA"}, want: true},
+		{flags: []string{"-runx"}, want: false},
+		{flags: []string{"-skip", "TestA"}, want: false},
+	}
+
+	for _, test := range tests {
+		if got := hasRunFlag(test.flags); got != test.want {
+			t.Errorf("hasRunFlag(%q) = %t, want %t", test.flags, got, test.want)
+		}
+	}
+}
+
+func TestRunPattern(t *testing.T) {
+	cov := TestCoverage{
+		"a.go": {
+			3: {"TestA"},
+			4: {"TestA", "TestB"},
+			5: nil,
+		},
+	}
+	pos := func(line int) token.Position { return token.Position{Filename: "/src/a.go", Line: line} }
+
+	tests := []struct {
+		name      string
+		positions []token.Position
+		want      string
+	}{
+		{name: "covered", positions: []token.Position{pos(3)}, want: "^(TestA)$"},
+		{name: "covered by several", positions: []token.Position{pos(4)}, want: "^(TestA|TestB)$"},
+		{name: "not covered", positions: []token.Position{pos(5)}, want: "^$"},
+		{name: "outside profiles", positions: []token.Position{pos(1)}, want: ""},
+		{name: "other file", positions: []token.Position{{Filename: "/src/b.go", Line: 3}}, want: ""},
+		{name: "union", positions: []token.Position{pos(3), pos(5), pos(4)}, want: "^(TestA|TestB)$"},
+		{name: "union outside profiles", positions: []token.Position{pos(3), pos(1)}, want: ""},
+	}
+
+	for _, test := range tests {
+		if got := cov.RunPattern(test.positions...); got != test.want {
+			t.Errorf("%s: RunPattern = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
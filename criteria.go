@@ -0,0 +1,308 @@
+package mutator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Mechanisms that kill mutants, as reported in Result.KilledBy
+const (
+	KilledByTests     = "tests"
+	KilledByFuzzing   = "fuzzing"
+	KilledByBenchmark = "benchmark"
+)
+
+// killedBy runs the fuzz test fuzz and the benchmarks selected by the runner's
+// options against a mutant that survived its tests, using the overlay substituting
+// its source. It returns the outcome, the output of the go test command that
+// killed the mutant if one did, and the mechanism that killed it. bench holds the
+// results of the benchmarks of the original package. Failures of go test other
+// than those of the fuzz test or benchmarks are returned as errors.
+func (r *Runner) killedBy(ctx context.Context, j *job, overlay, fuzz string, bench map[string]float64, dir string) (Outcome, []byte, string, error) {
+	pkgDir := filepath.Dir(j.srcFile)
+	if fuzz != "" {
+		outcome, output, err := r.fuzzMutant(ctx, pkgDir, overlay, fuzz, dir)
+		if err != nil {
+			return 0, nil, "", err
+		}
+		switch outcome {
+		case Killed:
+			return Killed, output, KilledByFuzzing, nil
+		case BuildError, TestError:
+			return 0, nil, "", fmt.Errorf("fuzz test failed:\n%s", output)
+		}
+	}
+
+	if bench != nil {
+		// Benchmarks aren't limited by go test -timeout, so a mutant that makes one
+		// loop forever is stopped here
+		benchCtx := ctx
+		if r.Timeout > 0 {
+			var cancel context.CancelFunc
+			benchCtx, cancel = context.WithTimeout(ctx, r.Timeout)
+			defer cancel()
+		}
+		args := append([]string{"test", "-overlay=" + overlay}, r.benchFlags()...)
+		outcome, output, err := goTest(benchCtx, r.Build, r.Docker, pkgDir, []string{dir}, args)
+		if benchCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			output = append(output, fmt.Sprintf("benchmarks timed out after %s\n", r.Timeout)...)
+			return Killed, output, KilledByBenchmark, nil
+		}
+		if err != nil {
+			return 0, nil, "", err
+		}
+		switch outcome {
+		case Killed:
+			return Killed, output, KilledByBenchmark, nil
+		case BuildError, TestError:
+			return 0, nil, "", fmt.Errorf("go test -bench failed:\n%s", output)
+		}
+		if regressions := r.benchRegressions(bench, parseBenchmarks(output)); len(regressions) > 0 {
+			return Killed, append(output, regressions...), KilledByBenchmark, nil
+		}
+	}
+	return Survived, nil, "", nil
+}
+
+// extraFlags returns the flags passed to go test when fuzzing or benchmarking a
+// mutant, apart from those selecting what's run
+func (r *Runner) extraFlags() []string {
+	var flags []string
+	if r.Timeout > 0 {
+		flags = append(flags, "-timeout", r.Timeout.String())
+	}
+	return append(flags, r.TestFlags...)
+}
+
+// benchFlags returns the flags passed to go test to run the selected benchmarks
+func (r *Runner) benchFlags() []string {
+	return append(r.extraFlags(), "-run=^$", "-bench="+r.Bench)
+}
+
+// fuzzTarget returns the name of the fuzz test of pkg selected by the Fuzz
+// pattern, which must match exactly one of them as go test -fuzz refuses to run
+// several
+func (r *Runner) fuzzTarget(ctx context.Context, pkg *Package) (string, error) {
+	outcome, output, err := goTest(ctx, r.Build, r.Docker, pkg.Dir, nil, []string{"test", "-list", r.Fuzz})
+	if err != nil {
+		return "", err
+	}
+	if outcome != Survived {
+		return "", fmt.Errorf("could not list fuzz tests:\n%s", output)
+	}
+	var targets []string
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		if name := s.Text(); strings.HasPrefix(name, "Fuzz") {
+			targets = append(targets, name)
+		}
+	}
+	switch len(targets) {
+	case 0:
+		return "", fmt.Errorf("no fuzz tests match %q", r.Fuzz)
+	case 1:
+		return targets[0], nil
+	}
+	return "", fmt.Errorf("%q matches more than one fuzz test: %s", r.Fuzz, strings.Join(targets, ", "))
+}
+
+// benchmarkOriginal runs the selected benchmarks against the original package
+// and returns their results in ns/op by name
+func (r *Runner) benchmarkOriginal(ctx context.Context, pkg *Package) (map[string]float64, error) {
+	args := append([]string{"test"}, r.benchFlags()...)
+	outcome, output, err := goTest(ctx, r.Build, r.Docker, pkg.Dir, nil, args)
+	if err != nil {
+		return nil, err
+	}
+	if outcome != Survived {
+		return nil, fmt.Errorf("benchmarks failed:\n%s", output)
+	}
+	bench := parseBenchmarks(output)
+	if len(bench) == 0 {
+		return nil, fmt.Errorf("no benchmarks match %q", r.Bench)
+	}
+	return bench, nil
+}
+
+// benchRegressions describes the benchmarks that are slower for the mutant than
+// for the original by more than BenchThreshold percent, or that weren't run
+func (r *Runner) benchRegressions(original, mutant map[string]float64) []byte {
+	var names []string
+	for name := range original {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		before, after := original[name], mutant[name]
+		switch {
+		case after == 0:
+			fmt.Fprintf(&b, "benchmark %s was not run\n", name)
+		case after > before*(1+r.BenchThreshold/100):
+			fmt.Fprintf(&b, "benchmark %s regressed from %.1f to %.1f ns/op (%+.1f%%)\n",
+				name, before, after, 100*(after-before)/before)
+		}
+	}
+	return b.Bytes()
+}
+
+// parseBenchmarks returns the ns/op of each benchmark in the output of go test -bench
+func parseBenchmarks(output []byte) map[string]float64 {
+	results := make(map[string]float64)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || fields[3] != "ns/op" {
+			continue
+		}
+		if ns, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			results[fields[0]] = ns
+		}
+	}
+	return results
+}
+
+// fuzzMutant runs the fuzz test fuzz against the mutant substituted by overlay
+// and returns the outcome and output. go test -fuzz writes the inputs that fail
+// to the testdata directory of the package, so the test binary is built in dir
+// and run there instead, in a tree of links to the package's files where only
+// the directory holding the fuzz test's corpus is real.
+func (r *Runner) fuzzMutant(ctx context.Context, pkgDir, overlay, fuzz, dir string) (Outcome, []byte, error) {
+	pattern := "^" + regexp.QuoteMeta(fuzz) + "$"
+	binary := filepath.Join(dir, "fuzz.test")
+	args := append([]string{"test", "-c", "-o", binary, "-overlay=" + overlay, "-fuzz=" + pattern}, r.TestFlags...)
+	build := r.Build.Command(ctx, args...)
+	build.Dir = pkgDir
+	if output, err := build.CombinedOutput(); err != nil {
+		return 0, nil, fmt.Errorf("could not build fuzz test: %s\n%s", err, output)
+	}
+
+	runDir := filepath.Join(dir, "fuzz")
+	defer os.RemoveAll(runDir)
+	if err := linkTree(pkgDir, runDir, "testdata", "fuzz", fuzz); err != nil {
+		return 0, nil, fmt.Errorf("could not set up fuzzing directory: %s", err)
+	}
+
+	binArgs := []string{"-test.run=^$", "-test.fuzz=" + pattern, "-test.fuzztime=" + r.FuzzTime.String(),
+		"-test.fuzzcachedir=" + filepath.Join(runDir, "cache")}
+	if r.Timeout > 0 {
+		binArgs = append(binArgs, "-test.timeout="+r.Timeout.String())
+	}
+	binArgs = append(binArgs, binaryFlags(r.TestFlags)...)
+
+	var cmd *exec.Cmd
+	if r.Docker != nil {
+		var remove func()
+		var err error
+		if cmd, remove, err = r.Docker.Exec(ctx, r.Build, pkgDir, runDir, []string{dir}, append([]string{binary}, binArgs...)...); err != nil {
+			return 0, nil, err
+		}
+		defer func() {
+			if ctx.Err() != nil {
+				remove()
+			}
+		}()
+	} else {
+		cmd = exec.CommandContext(ctx, binary, binArgs...)
+		setProcessGroup(cmd)
+		cmd.Dir = runDir
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return 0, nil, err
+		}
+		return classifyFailure(output), append(output, fuzzInputs(runDir, output)...), nil
+	}
+	return Survived, output, nil
+}
+
+// binaryTestFlags are the flags of go test that are passed on to the test binary
+// when fuzzing, mapped to whether they take a value
+var binaryTestFlags = map[string]bool{"cpu": true, "parallel": true, "short": false, "v": false}
+
+// binaryFlags returns the flags among the go test flags that must be passed to
+// the test binary when it's run directly, with the test. prefix it expects
+func binaryFlags(flags []string) []string {
+	var out []string
+	for i := 0; i < len(flags); i++ {
+		name := strings.TrimLeft(flags[i], "-")
+		value, hasValue := "", false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+		name = strings.TrimPrefix(name, "test.")
+		takesValue, ok := binaryTestFlags[name]
+		if !ok {
+			continue
+		}
+		if takesValue && !hasValue && i+1 < len(flags) {
+			i++
+			value, hasValue = flags[i], true
+		}
+		if hasValue {
+			out = append(out, "-test."+name+"="+value)
+		} else {
+			out = append(out, "-test."+name)
+		}
+	}
+	return out
+}
+
+// linkTree creates the directory dst holding links to the entries of the
+// directory src, apart from the one along path, which is created as a directory
+// of its own in the same way
+func linkTree(src, dst string, path ...string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(src)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if len(path) > 0 && entry.Name() == path[0] && entry.IsDir() {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if len(path) == 0 {
+		return nil
+	}
+	return linkTree(filepath.Join(src, path[0]), filepath.Join(dst, path[0]), path[1:]...)
+}
+
+// fuzzInputs returns the contents of the failing inputs the fuzz test run in
+// runDir wrote, as named in its output, since they're removed along with it
+func fuzzInputs(runDir string, output []byte) []byte {
+	const prefix = "Failing input written to "
+	var inputs []byte
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		path := strings.TrimPrefix(line, prefix)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(runDir, path)
+		}
+		if data, err := ioutil.ReadFile(path); err == nil {
+			inputs = append(inputs, fmt.Sprintf("failing input %s:\n%s", filepath.Base(path), data)...)
+		}
+	}
+	return inputs
+}
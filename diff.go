@@ -0,0 +1,189 @@
+package mutator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ChangedLines maps absolute file names to the set of lines that were added or modified
+type ChangedLines map[string]map[int]bool
+
+// Contains reports whether the line at pos was changed
+func (c ChangedLines) Contains(pos token.Position) bool {
+	if lines, ok := c[pos.Filename]; ok {
+		return lines[pos.Line]
+	}
+	// The diff paths are resolved, so the file may be known under its real name
+	if path, err := filepath.EvalSymlinks(pos.Filename); err == nil {
+		return c[path][pos.Line]
+	}
+	return false
+}
+
+// GitChangedLines returns the lines changed in the working tree relative to the git ref.
+// Every line of the untracked files that aren't ignored counts as changed.
+func GitChangedLines(ref string) (ChangedLines, error) {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not find git repository: %s", err)
+	}
+	root := string(bytes.TrimSpace(out))
+
+	cmd := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "-U0", ref)
+	cmd.Stderr = os.Stderr
+	if out, err = cmd.Output(); err != nil {
+		return nil, fmt.Errorf("could not diff against %s: %s", ref, err)
+	}
+	changed, err := ParseDiff(bytes.NewReader(out), root)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd = exec.Command("git", "ls-files", "-z", "--others", "--exclude-standard")
+	cmd.Dir = root
+	cmd.Stderr = os.Stderr
+	if out, err = cmd.Output(); err != nil {
+		return nil, fmt.Errorf("could not list untracked files: %s", err)
+	}
+	if path, err := filepath.EvalSymlinks(root); err == nil {
+		root = path
+	}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(name))
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read untracked file: %s", err)
+		}
+		n := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			n++
+		}
+		lines := make(map[int]bool)
+		for line := 1; line <= n; line++ {
+			lines[line] = true
+		}
+		changed[path] = lines
+	}
+	return changed, nil
+}
+
+// ParseDiff returns the lines added or modified by the unified diff read from r.
+// File names in the diff are interpreted relative to root.
+func ParseDiff(r io.Reader, root string) (ChangedLines, error) {
+	if path, err := filepath.EvalSymlinks(root); err == nil {
+		root = path
+	}
+
+	changed := make(ChangedLines)
+	var lines map[int]bool
+	// line is the next line of the new file, and oldLeft and newLeft count the
+	// lines of the old and new files remaining in the current hunk
+	var line, oldLeft, newLeft int
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		text := s.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if lines != nil {
+					lines[line] = true
+				}
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, " "), text == "":
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name, err := diffFileName(text[4:])
+			if err != nil {
+				return nil, err
+			}
+			if name == "/dev/null" {
+				lines = nil
+				continue
+			}
+			path := filepath.Join(root, strings.TrimPrefix(name, "b/"))
+			if changed[path] == nil {
+				changed[path] = make(map[int]bool)
+			}
+			lines = changed[path]
+		case strings.HasPrefix(text, "@@ "):
+			var err error
+			if line, oldLeft, newLeft, err = parseHunk(text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return changed, s.Err()
+}
+
+// diffFileName returns the file name in a ---/+++ line of a diff, which git
+// quotes if it has unusual characters and other tools may follow with a tab
+// and a timestamp
+func diffFileName(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.LastIndexByte(s, '"'); end > 0 {
+			if name, err := strconv.Unquote(s[:end+1]); err == nil {
+				return name, nil
+			}
+		}
+		return "", fmt.Errorf("malformed file name: %s", s)
+	}
+	if tab := strings.IndexByte(s, '\t'); tab >= 0 {
+		s = s[:tab]
+	}
+	return strings.TrimRight(s, " "), nil
+}
+
+// parseHunk returns the first new line and the numbers of old and new lines from
+// a hunk header of the form @@ -l,s +l,s @@, where a missing size means one line
+func parseHunk(header string) (start, oldLines, newLines int, err error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	_, oldLines, err = parseRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	start, newLines, err = parseRange(fields[2][1:])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header: %s", header)
+	}
+	return start, oldLines, newLines, nil
+}
+
+// parseRange parses the range l,s of a hunk header
+func parseRange(s string) (start, size int, err error) {
+	parts := strings.SplitN(s, ",", 2)
+	if start, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	size = 1
+	if len(parts) == 2 {
+		if size, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, size, nil
+}
@@ -0,0 +1,215 @@
+package mutator
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	root := t.TempDir()
+	if path, err := filepath.EvalSymlinks(root); err == nil {
+		root = path
+	}
+	file := func(name string) string { return filepath.Join(root, name) }
+
+	tests := []struct {
+		name string
+		diff string
+		want ChangedLines
+		err  string
+	}{
+		{
+			name: "added and modified lines",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -3 +3 @@ func A() {
+-	return 1
++	return 2
+@@ -10,0 +11,2 @@ func B() {
++	x++
++	y++
+`,
+			want: ChangedLines{file("a.go"): {3: true, 11: true, 12: true}},
+		},
+		{
+			name: "context lines",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -1,4 +1,4 @@
+ package a
+-var x = 1
++var x = 2
+
+ var y = 3
+`,
+			want: ChangedLines{file("a.go"): {2: true}},
+		},
+		{
+			name: "removed lines only",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -5,2 +4,0 @@
+-	x++
+-	y++
+`,
+			want: ChangedLines{file("a.go"): {}},
+		},
+		{
+			name: "new file",
+			diff: `diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package a
++var x = 1
+`,
+			want: ChangedLines{file("new.go"): {1: true, 2: true}},
+		},
+		{
+			name: "deleted file",
+			diff: `diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package a
+`,
+			want: ChangedLines{},
+		},
+		{
+			name: "rename",
+			diff: `diff --git a/old.go b/dir/new.go
+similarity index 90%
+rename from old.go
+rename to dir/new.go
+--- a/old.go
++++ b/dir/new.go
+@@ -2 +2 @@
+-var x = 1
++var x = 2
+`,
+			want: ChangedLines{file("dir/new.go"): {2: true}},
+		},
+		{
+			name: "no newline at end of file",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-var x = 1
+\ No newline at end of file
++var x = 2
+\ No newline at end of file
+@@ -5 +5 @@
+-var y = 1
++var y = 2
+`,
+			want: ChangedLines{file("a.go"): {1: true, 5: true}},
+		},
+		{
+			name: "added lines that look like headers",
+			diff: `--- a/a.go
++++ b/a.go
+@@ -0,0 +1,2 @@
++++ b/b.go
++@@ -1 +1 @@
+`,
+			want: ChangedLines{file("a.go"): {1: true, 2: true}},
+		},
+		{
+			name: "quoted file name",
+			diff: `--- "a/a b.go"
++++ "b/a b.go"
+@@ -1 +1 @@
+-x
++y
+`,
+			want: ChangedLines{file("a b.go"): {1: true}},
+		},
+		{
+			name: "timestamps after file names",
+			diff: "--- a.go\t2024-01-01 00:00:00\n+++ a.go\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-x\n+y\n",
+			want: ChangedLines{file("a.go"): {1: true}},
+		},
+		{
+			name: "malformed hunk header",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -1 @@\n",
+			err:  "malformed hunk header: @@ -1 @@",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changed, err := ParseDiff(strings.NewReader(test.diff), root)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changed, test.want) {
+				t.Errorf("got %v, want %v", changed, test.want)
+			}
+		})
+	}
+}
+
+func TestGitChangedLines(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	root := t.TempDir()
+	if path, err := filepath.EvalSymlinks(root); err == nil {
+		root = path
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s\n%s", args[0], err, out)
+		}
+	}
+	write := func(name, src string) {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("a.go", "package p\n\nvar a = 1\n")
+	write(".gitignore", "ignored.go\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	write("a.go", "package p\n\nvar a = 2\n")
+	write("new.go", "package p\n\nvar b = 1\n")
+	write("ignored.go", "package p\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	changed, err := GitChangedLines("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ChangedLines{
+		filepath.Join(root, "a.go"):   {3: true},
+		filepath.Join(root, "new.go"): {1: true, 2: true, 3: true},
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("got %v, want %v", changed, want)
+	}
+}
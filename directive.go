@@ -0,0 +1,110 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const disableDirective = "//mutator:disable"
+
+// directive is a region of a file in which mutation has been disabled
+type directive struct {
+	pos, end token.Pos
+
+	// categories is the set of disabled categories, or nil if all are disabled
+	categories map[string]bool
+}
+
+// parseDirectives finds the //mutator:disable comments in file and the nodes they
+// apply to. A directive applies to the node it is attached to by ast.CommentMap,
+// so it can annotate a statement or a whole function. A directive at the end of
+// a line applies to that line instead, and to the node only if it starts there.
+func parseDirectives(fset *token.FileSet, file *ast.File) []directive {
+	var directives []directive
+	for node, groups := range ast.NewCommentMap(fset, file, file.Comments) {
+		for _, group := range groups {
+			for _, c := range group.List {
+				d, ok := parseDirective(c.Text)
+				if !ok {
+					continue
+				}
+				line := fset.Position(c.Pos()).Line
+				if trailing(fset, file, c) {
+					tf := fset.File(c.Pos())
+					d.pos, d.end = tf.LineStart(line), token.Pos(tf.Base()+tf.Size())
+					if line < tf.LineCount() {
+						d.end = tf.LineStart(line + 1)
+					}
+					directives = append(directives, d)
+					if fset.Position(node.Pos()).Line != line {
+						continue
+					}
+				}
+				d.pos, d.end = node.Pos(), node.End()
+				directives = append(directives, d)
+			}
+		}
+	}
+	return directives
+}
+
+// trailing reports whether c follows code on its line
+func trailing(fset *token.FileSet, file *ast.File, c *ast.Comment) bool {
+	line := fset.Position(c.Pos()).Line
+	found := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node.(type) {
+		case nil, *ast.File, *ast.CommentGroup, *ast.Comment:
+			return !found
+		}
+		if node.Pos() < c.Pos() && fset.Position(node.End()).Line == line {
+			found = true
+		}
+		return !found && node.Pos() < c.Pos()
+	})
+	return found
+}
+
+// parseDirective parses a single comment, reporting whether it is a disable directive
+func parseDirective(text string) (directive, bool) {
+	if !strings.HasPrefix(text, disableDirective) {
+		return directive{}, false
+	}
+	rest := strings.TrimSpace(text[len(disableDirective):])
+	if rest == "" {
+		return directive{}, true
+	}
+	if !strings.HasPrefix(rest, "=") {
+		return directive{}, false
+	}
+
+	d := directive{categories: make(map[string]bool)}
+	for _, cat := range strings.Split(rest[1:], ",") {
+		d.categories[strings.TrimSpace(cat)] = true
+	}
+	return d, true
+}
+
+// FilterDisabled splits mutations into those that should be tested and those
+// that have been disabled by a //mutator:disable comment in file.
+func FilterDisabled(fset *token.FileSet, file *ast.File, mutants []Mutant) (enabled, ignored []Mutant) {
+	directives := parseDirectives(fset, file)
+	for _, m := range mutants {
+		if disabled(directives, m) {
+			ignored = append(ignored, m)
+		} else {
+			enabled = append(enabled, m)
+		}
+	}
+	return enabled, ignored
+}
+
+func disabled(directives []directive, m Mutant) bool {
+	for _, d := range directives {
+		if m.Pos >= d.pos && m.Pos < d.end && (d.categories == nil || d.categories[m.Category]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package mutator
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestFilterDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		// want are the lines of the mutants that are tested, and ignored those
+		// that are disabled
+		want, ignored []int
+	}{
+		{
+			name: "none",
+			src: `func F(a int) int {
+	b := a + 1
+	return b * 2
+}`,
+			want: []int{4, 5},
+		},
+		{
+			name: "line",
+			src: `func F(a int) int {
+	b := a + 1 //mutator:disable
+	return b * 2
+}`,
+			want:    []int{5},
+			ignored: []int{4},
+		},
+		{
+			name: "line before",
+			src: `func F(a int) int {
+	//mutator:disable
+	b := a + 1
+	return b * 2
+}`,
+			want:    []int{6},
+			ignored: []int{5},
+		},
+		{
+			name: "function",
+			src: `//mutator:disable
+func F(a int) int {
+	b := a + 1
+	return b * 2
+}
+
+func G(a int) int {
+	return a + 1
+}`,
+			want:    []int{10},
+			ignored: []int{5, 6},
+		},
+		{
+			name: "function in a doc comment",
+			src: `// F does things.
+//
+//mutator:disable
+func F(a int) int {
+	return a + 1
+}`,
+			ignored: []int{7},
+		},
+		{
+			name: "block",
+			src: `func F(a int) int {
+	if a > 0 { //mutator:disable
+		a = a + 1
+	}
+	return a * 2
+}`,
+			want:    []int{5, 7},
+			ignored: []int{4},
+		},
+		{
+			name: "category",
+			src: `func F(a, b int) bool {
+	return a+1 < b //mutator:disable=comparison
+}`,
+			want:    []int{4},
+			ignored: []int{4},
+		},
+		{
+			name: "other comment",
+			src: `func F(a int) int {
+	return a + 1 // mutator:disable is not a directive
+}`,
+			want: []int{4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "p.go", "package p\n\n"+test.src+"\n", parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mutants := FindMutants(file, nil, map[string]bool{"arithmetic": true, "comparison": true})
+			enabled, ignored := FilterDisabled(fset, file, mutants)
+			lines := func(mutants []Mutant) []int {
+				var lines []int
+				for _, m := range mutants {
+					lines = append(lines, fset.Position(m.Pos).Line)
+				}
+				return lines
+			}
+			if got := lines(enabled); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got mutants on lines %v, want %v", got, test.want)
+			}
+			if got := lines(ignored); !reflect.DeepEqual(got, test.ignored) {
+				t.Errorf("got disabled mutants on lines %v, want %v", got, test.ignored)
+			}
+		})
+	}
+}
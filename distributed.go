@@ -0,0 +1,414 @@
+package mutator
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Work is a mutant handed out by a Server for a remote worker to test
+type Work struct {
+	// Seq identifies the work within the run. It's zero when there is no more work.
+	Seq int
+
+	// Package is the import path of the mutated package
+	Package string
+
+	// Build holds the build settings to test the package with
+	Build BuildConfig
+
+	// File is the base name of the mutated file
+	File string
+
+	// Src is the mutated source of the file
+	Src []byte
+
+	// ID identifies the mutant in messages
+	ID string
+
+	// TestFlags are the flags passed to go test, apart from -overlay
+	TestFlags []string
+}
+
+// WorkResult is the outcome of testing a Work
+type WorkResult struct {
+	Seq      int
+	Outcome  Outcome
+	KilledBy string
+	Output   []byte
+	Duration time.Duration
+
+	// Err describes why the mutant couldn't be tested, if it couldn't
+	Err string
+}
+
+// Server hands out the mutants of a run to remote workers connected over the
+// network, which test them in their own checkout of the repository. Work taken
+// by a worker that disconnects is handed out again.
+type Server struct {
+	// Token is the secret shared with the workers, which must present it before
+	// they're given any work
+	Token string
+
+	// Log, if non-nil, receives a message when a worker connects or disconnects
+	Log *Logger
+
+	once    sync.Once
+	work    chan *pending
+	stop    chan struct{}
+	mu      sync.Mutex
+	seq     int
+	workers int
+	conns   sync.WaitGroup
+}
+
+// pending is work waiting for its result
+type pending struct {
+	work Work
+	done chan WorkResult
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		s.work = make(chan *pending)
+		s.stop = make(chan struct{})
+	})
+}
+
+// Serve accepts connections from workers on l until it's closed
+func (s *Server) Serve(l net.Listener) error {
+	s.init()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// closeTimeout is how long Close waits for workers to disconnect
+const closeTimeout = 5 * time.Second
+
+// Close tells the workers that there is no more work, and waits for them to disconnect
+func (s *Server) Close() {
+	s.init()
+	close(s.stop)
+
+	done := make(chan struct{})
+	go func() {
+		s.conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(closeTimeout):
+	}
+}
+
+// serveConn serves a worker until it disconnects
+func (s *Server) serveConn(conn net.Conn) {
+	s.conns.Add(1)
+	defer s.conns.Done()
+	s.mu.Lock()
+	s.workers++
+	s.Log.Printf(LevelNormal, "worker %s connected, %d connected\n", conn.RemoteAddr(), s.workers)
+	s.mu.Unlock()
+
+	w := &workerConn{s: s, assigned: make(map[int]*pending)}
+	srv := rpc.NewServer()
+	srv.RegisterName("Coordinator", w)
+	srv.ServeConn(conn)
+
+	w.mu.Lock()
+	w.closed = true
+	for _, p := range w.assigned {
+		go s.requeue(p)
+	}
+	w.mu.Unlock()
+
+	s.mu.Lock()
+	s.workers--
+	s.Log.Printf(LevelNormal, "worker %s disconnected, %d connected\n", conn.RemoteAddr(), s.workers)
+	s.mu.Unlock()
+}
+
+// requeue hands out p again after the worker it was assigned to disconnected
+func (s *Server) requeue(p *pending) {
+	select {
+	case s.work <- p:
+	case <-s.stop:
+	}
+}
+
+// test waits for a worker to test j with the given test flags, and sets its result
+func (s *Server) test(ctx context.Context, j *job, b BuildConfig, testFlags []string) error {
+	s.init()
+	s.mu.Lock()
+	s.seq++
+	p := &pending{
+		work: Work{
+			Seq:       s.seq,
+			Package:   j.result.Package,
+			Build:     b,
+			File:      filepath.Base(j.srcFile),
+			Src:       j.src,
+			ID:        j.result.ID,
+			TestFlags: testFlags,
+		},
+		done: make(chan WorkResult, 1),
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.work <- p:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case res := <-p.done:
+		if res.Err != "" {
+			return fmt.Errorf("mutation %s could not be tested by a worker: %s", j.result.ID, res.Err)
+		}
+		j.result.Outcome, j.result.KilledBy = res.Outcome, res.KilledBy
+		j.result.Output, j.result.Duration = res.Output, res.Duration
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// workerConn is the RPC service for a single worker, which tracks the work
+// assigned to it
+type workerConn struct {
+	s          *Server
+	mu         sync.Mutex
+	assigned   map[int]*pending
+	closed     bool
+	authorized bool
+}
+
+// errUnauthorized is returned to workers that haven't presented the server's token
+var errUnauthorized = errors.New("worker hasn't presented the coordinator's token")
+
+// Auth authorizes the worker if it presents the server's token
+func (w *workerConn) Auth(token string, _ *struct{}) error {
+	if w.s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(w.s.Token)) != 1 {
+		return errors.New("invalid token")
+	}
+	w.mu.Lock()
+	w.authorized = true
+	w.mu.Unlock()
+	return nil
+}
+
+// isAuthorized reports whether the worker has presented the server's token
+func (w *workerConn) isAuthorized() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.authorized
+}
+
+// Next waits for work and assigns it to the worker
+func (w *workerConn) Next(name string, work *Work) error {
+	if !w.isAuthorized() {
+		return errUnauthorized
+	}
+	select {
+	case p := <-w.s.work:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.closed {
+			go w.s.requeue(p)
+			return errors.New("worker disconnected")
+		}
+		w.assigned[p.work.Seq] = p
+		*work = p.work
+	case <-w.s.stop:
+		*work = Work{}
+	}
+	return nil
+}
+
+// Done reports the result of work assigned to the worker
+func (w *workerConn) Done(res WorkResult, _ *struct{}) error {
+	if !w.isAuthorized() {
+		return errUnauthorized
+	}
+	w.mu.Lock()
+	p, ok := w.assigned[res.Seq]
+	delete(w.assigned, res.Seq)
+	w.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("work %d isn't assigned to this worker", res.Seq)
+	}
+	p.done <- res
+	return nil
+}
+
+// Worker tests the mutants handed out by a Server. It must run in a checkout of
+// the same revision of the repository as the server.
+type Worker struct {
+	// Token is the secret shared with the server
+	Token string
+
+	// Parallel is the number of mutants to test at once
+	Parallel int
+
+	// Docker, if non-nil, runs the tests in a container rather than on the host
+	Docker *Docker
+
+	// Log, if non-nil, receives a message for each tested mutant, along with its
+	// test output at LevelVerbose
+	Log *Logger
+
+	mu   sync.Mutex
+	dirs map[string]string
+}
+
+// Run connects to the server at addr and tests mutants until there are none left
+// or ctx is done
+func (w *Worker) Run(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	client := rpc.NewClient(conn)
+	defer client.Close()
+	go func() {
+		<-ctx.Done()
+		client.Close()
+	}()
+	if err := client.Call("Coordinator.Auth", w.Token, nil); err != nil {
+		return fmt.Errorf("could not authenticate with the coordinator: %s", err)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "mutate")
+	if err != nil {
+		return fmt.Errorf("could not create temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	name, _ := os.Hostname()
+	parallel := w.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	errs := make(chan error, parallel)
+	for i := 0; i < parallel; i++ {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("worker%d", i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return fmt.Errorf("could not create worker directory: %s", err)
+		}
+		go func() {
+			for {
+				var work Work
+				if err := client.Call("Coordinator.Next", name, &work); err != nil {
+					errs <- err
+					return
+				}
+				if work.Seq == 0 {
+					errs <- nil
+					return
+				}
+				res := w.test(ctx, &work, dir)
+				if ctx.Err() != nil {
+					errs <- ctx.Err()
+					return
+				}
+				w.Log.Printf(LevelNormal, "mutation %s %s\n", work.ID, res.Outcome)
+				w.Log.Printf(LevelVerbose, "%s", indent(res.Output))
+				if err := client.Call("Coordinator.Done", res, nil); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var firstErr error
+	for i := 0; i < parallel; i++ {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// test tests work using dir for the mutated source
+func (w *Worker) test(ctx context.Context, work *Work, dir string) WorkResult {
+	res := WorkResult{Seq: work.Seq}
+	// The server's build cache is of no use here, and the go tool's default is used
+	b := work.Build
+	b.GOCACHE = ""
+	pkgDir, err := w.packageDir(ctx, b, work.Package, work.TestFlags)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+
+	mutatedFile := filepath.Join(dir, work.File)
+	if err := ioutil.WriteFile(mutatedFile, work.Src, 0644); err != nil {
+		res.Err = fmt.Sprintf("could not write mutated file: %s", err)
+		return res
+	}
+	overlay := filepath.Join(dir, "overlay.json")
+	if err := writeOverlay(overlay, map[string]string{filepath.Join(pkgDir, work.File): mutatedFile}); err != nil {
+		res.Err = fmt.Sprintf("could not write overlay: %s", err)
+		return res
+	}
+
+	start := time.Now()
+	args := append([]string{"test", "-overlay=" + overlay}, work.TestFlags...)
+	res.Outcome, res.Output, err = goTest(ctx, b, w.Docker, pkgDir, []string{dir}, args)
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Err = err.Error()
+	}
+	if res.Outcome == Killed {
+		res.KilledBy = KilledByTests
+	}
+	return res
+}
+
+// packageDir returns the directory of the named package in the worker's checkout.
+// The first time a package is found its dependencies are built with testFlags.
+func (w *Worker) packageDir(ctx context.Context, b BuildConfig, name string, testFlags []string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	key := b.String() + " " + name
+	if dir, ok := w.dirs[key]; ok {
+		return dir, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	pkg, err := b.Context().Import(name, wd, build.FindOnly)
+	if err != nil {
+		return "", fmt.Errorf("could not find %s: %s", name, err)
+	}
+	w.Log.Printf(LevelVerbose, "building dependencies of %s\n", name)
+	if err := warmCache(ctx, b, w.Docker, pkg.Dir, testFlags); err != nil && ctx.Err() == nil {
+		w.Log.Printf(LevelNormal, "%s\n", err)
+	}
+	if w.dirs == nil {
+		w.dirs = make(map[string]string)
+	}
+	w.dirs[key] = pkg.Dir
+	return pkg.Dir, nil
+}
@@ -0,0 +1,128 @@
+package mutator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// Docker runs the tests of mutants in containers, so that a mutant which deletes
+// files or fills the disk can't harm the machine running mutator. The package's
+// source tree and the mutated file are mounted read-only at the same paths as
+// on the host, and the container has no network access.
+type Docker struct {
+	// Image is the container image to run the tests in. It must provide a go
+	// tool that supports -overlay and is on the PATH.
+	Image string
+
+	// CacheVolume, if non-empty, is the name of a docker volume holding a build
+	// cache shared by the containers, so that dependencies are compiled once
+	// rather than in every container
+	CacheVolume string
+
+	mu   sync.Mutex
+	envs map[string]goEnv
+}
+
+// goEnv holds the settings of the go tool that determine what must be mounted
+type goEnv struct {
+	GOMOD      string
+	GOPATH     string
+	GOMODCACHE string
+}
+
+// Command returns a command running the go tool with the given arguments in a
+// container, in the directory pkgDir. The settings of b are passed to it the same
+// way as by BuildConfig.Command. The directories in mounts, which hold the
+// mutated source, are mounted read-only as well as the source tree of pkgDir.
+// If ctx is done the container must be removed by calling the returned function,
+// since stopping the docker client doesn't stop the container.
+func (d *Docker) Command(ctx context.Context, b BuildConfig, pkgDir string, mounts []string, args ...string) (*exec.Cmd, func(), error) {
+	// BuildConfig.Command places the build flags among the arguments
+	return d.command(ctx, b, pkgDir, pkgDir, mounts, b.Command(ctx, args...).Args)
+}
+
+// Exec returns a command running the program argv[0], such as a test binary, in
+// a container, in the directory dir, which is mounted writable. Otherwise it's
+// run as by Command for the package in pkgDir.
+func (d *Docker) Exec(ctx context.Context, b BuildConfig, pkgDir, dir string, mounts []string, argv ...string) (*exec.Cmd, func(), error) {
+	return d.command(ctx, b, pkgDir, dir, mounts, argv)
+}
+
+// command returns a command running argv in a container in workDir, which is
+// mounted writable unless it's pkgDir
+func (d *Docker) command(ctx context.Context, b BuildConfig, pkgDir, workDir string, mounts []string, argv []string) (*exec.Cmd, func(), error) {
+	env, err := d.env(pkgDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, nil, err
+	}
+	name := "mutator-" + hex.EncodeToString(id[:])
+
+	// The build cache must be writable. Without a volume it's discarded with the container.
+	dockerArgs := []string{"run", "--rm", "--name", name, "--network", "none", "-w", workDir,
+		"-e", "GOCACHE=/tmp/go-build", "-e", "GOFLAGS=-buildvcs=false"}
+	if d.CacheVolume != "" {
+		dockerArgs = append(dockerArgs, "-v", d.CacheVolume+":/tmp/go-build")
+	}
+	if env.GOMOD != "" && env.GOMOD != "/dev/null" {
+		mounts = append(mounts, filepath.Dir(env.GOMOD), env.GOMODCACHE)
+		dockerArgs = append(dockerArgs, "-e", "GOMODCACHE="+env.GOMODCACHE, "-e", "GOPROXY=off")
+	} else {
+		mounts = append(mounts, filepath.SplitList(env.GOPATH)...)
+		dockerArgs = append(dockerArgs, "-e", "GOPATH="+env.GOPATH, "-e", "GO111MODULE=off")
+	}
+	for _, dir := range mounts {
+		dockerArgs = append(dockerArgs, "-v", dir+":"+dir+":ro")
+	}
+	if workDir != pkgDir {
+		dockerArgs = append(dockerArgs, "-v", workDir+":"+workDir)
+	}
+	if b.GOOS != "" {
+		dockerArgs = append(dockerArgs, "-e", "GOOS="+b.GOOS)
+	}
+	if b.GOARCH != "" {
+		dockerArgs = append(dockerArgs, "-e", "GOARCH="+b.GOARCH)
+	}
+
+	dockerArgs = append(append(dockerArgs, d.Image), argv...)
+	cmd := exec.CommandContext(ctx, "docker", dockerArgs...)
+	remove := func() {
+		exec.Command("docker", "rm", "-f", name).Run()
+	}
+	return cmd, remove, nil
+}
+
+// env returns the settings of the go tool on the host for the package in pkgDir
+func (d *Docker) env(pkgDir string) (goEnv, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if env, ok := d.envs[pkgDir]; ok {
+		return env, nil
+	}
+
+	cmd := exec.Command("go", "env", "-json", "GOMOD", "GOPATH", "GOMODCACHE")
+	cmd.Dir = pkgDir
+	out, err := cmd.Output()
+	if err != nil {
+		return goEnv{}, fmt.Errorf("could not read go env: %s", err)
+	}
+	var env goEnv
+	if err := json.Unmarshal(out, &env); err != nil {
+		return goEnv{}, fmt.Errorf("could not read go env: %s", err)
+	}
+	if d.envs == nil {
+		d.envs = make(map[string]goEnv)
+	}
+	d.envs[pkgDir] = env
+	return env, nil
+}
@@ -0,0 +1,116 @@
+package mutator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"debug/elf"
+	"debug/macho"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// buildTestBinary compiles the tests of the package in pkgDir to the binary out.
+// If overlay is non-empty it's passed to go test -overlay.
+func (r *Runner) buildTestBinary(ctx context.Context, pkgDir, out, overlay string) ([]byte, error) {
+	args := []string{"test", "-c", "-o", out}
+	if overlay != "" {
+		args = append(args, "-overlay="+overlay)
+	}
+	args = append(args, r.TestFlags...)
+	cmd := r.Build.Command(ctx, args...)
+	cmd.Dir = pkgDir
+	return cmd.CombinedOutput()
+}
+
+// binaryHash returns a hash of the test binary at path. The binary's build ID is
+// left out, along with the IDs the linker derives from it, since it's derived
+// from the source files and so differs even when the compiled code is the same.
+func (r *Runner) binaryHash(ctx context.Context, path string) (string, error) {
+	id, err := r.Build.Command(ctx, "tool", "buildid", path).Output()
+	if err != nil {
+		return "", fmt.Errorf("could not read build ID of %s: %s", filepath.Base(path), err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	ids := append(linkerIDs(path), bytes.TrimSpace(id))
+	for _, id := range ids {
+		if len(id) > 0 {
+			data = bytes.ReplaceAll(data, id, make([]byte, len(id)))
+		}
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// lcUUID is the Mach-O load command holding the binary's UUID
+const lcUUID = 0x1b
+
+// linkerIDs returns the identifiers the linker writes to the binary at path
+// based on its build ID: the GNU build ID note of ELF binaries and the UUID of
+// Mach-O binaries
+func linkerIDs(path string) [][]byte {
+	var ids [][]byte
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		if s := f.Section(".note.gnu.build-id"); s != nil {
+			if note, err := s.Data(); err == nil {
+				ids = append(ids, note)
+			}
+		}
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		for _, load := range f.Loads {
+			raw := load.Raw()
+			if len(raw) >= 24 && f.ByteOrder.Uint32(raw) == lcUUID {
+				ids = append(ids, raw[8:24])
+			}
+		}
+	}
+	return ids
+}
+
+// originalHashes returns the hashes of the test binaries of the unmutated package
+// for each of its files that has mutants, by file name. Mutants are printed from
+// the syntax tree, so the binary they're compared with substitutes the printed
+// source of the same file: other formatting would change its line tables.
+// The binaries are built in tmpDir.
+func (r *Runner) originalHashes(ctx context.Context, pkg *Package, mutants [][]Mutant, tmpDir string) (map[string]string, error) {
+	dir := filepath.Join(tmpDir, "original")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create directory for the original: %s", err)
+	}
+	hashes := make(map[string]string)
+	for i, file := range pkg.Files {
+		if len(mutants[i]) == 0 {
+			continue
+		}
+		srcFile := pkg.Fset.File(file.Pos()).Name()
+		src, err := pkg.Source(file, nil)
+		if err != nil {
+			return nil, err
+		}
+		printed := filepath.Join(dir, filepath.Base(srcFile))
+		if err := ioutil.WriteFile(printed, src, 0644); err != nil {
+			return nil, fmt.Errorf("could not write printed file: %s", err)
+		}
+		overlay := filepath.Join(dir, "overlay.json")
+		if err := writeOverlay(overlay, map[string]string{srcFile: printed}); err != nil {
+			return nil, fmt.Errorf("could not write overlay: %s", err)
+		}
+		out := filepath.Join(dir, "original.test")
+		if output, err := r.buildTestBinary(ctx, pkg.Dir, out, overlay); err != nil {
+			return nil, fmt.Errorf("could not build tests: %s\n%s", err, output)
+		}
+		if hashes[srcFile], err = r.binaryHash(ctx, out); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
@@ -0,0 +1,91 @@
+package mutator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resultRecorder is a Reporter keeping every result
+type resultRecorder []Result
+
+func (r *resultRecorder) Report(result Result) {
+	*r = append(*r, result)
+}
+
+func TestEquivalentMutants(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds test binaries")
+	}
+
+	// The blank lines and the spacing of x*1 aren't kept by go/printer, so the
+	// original only matches the mutant if both are printed the same way
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/eq\n\ngo 1.21\n",
+		"eq.go": `package eq
+
+
+
+// Scale returns x, scaled if big is set
+func Scale(x int, big bool) int {
+	if big {
+		return x*1 // untested
+	}
+	return x + 1
+}
+`,
+		"eq_test.go": `package eq
+
+import "testing"
+
+func TestScale(t *testing.T) {
+	if Scale(2, false) != 3 {
+		t.Fatal("wrong result")
+	}
+}
+`,
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var results resultRecorder
+	r := &Runner{
+		Options: Options{
+			Categories: map[string]bool{"arithmetic": true},
+			Equivalent: true,
+			Parallel:   1,
+		},
+		Reporter: &results,
+	}
+	if _, err := r.Run(context.Background(), "."); err != nil {
+		t.Fatal(err)
+	}
+
+	outcomes := make(map[string]Outcome)
+	for _, result := range results {
+		outcomes[result.Mutant.Original+" -> "+result.Mutant.Replacement] = result.Outcome
+	}
+	want := map[string]Outcome{
+		"* -> /": Equivalent,
+		"+ -> -": Killed,
+	}
+	for change, outcome := range want {
+		if outcomes[change] != outcome {
+			t.Errorf("mutation %s: got %s, want %s", change, outcomes[change], outcome)
+		}
+	}
+}
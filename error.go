@@ -0,0 +1,182 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// ErrorVisitor finds error handling that can be undone: errors returned from
+// if err != nil blocks, errors assigned from calls returning several values,
+// and the targets of errors.Is and errors.As.
+type ErrorVisitor struct {
+	// Info is used to find values of type error. Only errors.Is and errors.As
+	// are mutated without it.
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+
+	// uses, if non-nil, counts the uses of the objects in the file that can't be left unused
+	uses map[types.Object]int
+}
+
+func (v *ErrorVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.IfStmt:
+		if !v.isErrCheck(n.Cond) {
+			break
+		}
+		// Only returns directly in the block, where the error is known to be non-nil
+		for _, stmt := range n.Body.List {
+			ret, ok := stmt.(*ast.ReturnStmt)
+			if !ok {
+				continue
+			}
+			for i, res := range ret.Results {
+				if v.isError(res) && !isNil(v.Info, res) && !usesLast(v.Info, v.uses, res) {
+					v.replace(ret, res.Pos(), &ret.Results[i], &ast.Ident{NamePos: res.Pos(), Name: "nil"})
+				}
+			}
+		}
+	case *ast.AssignStmt:
+		if len(n.Lhs) < 2 || len(n.Rhs) != 1 {
+			break
+		}
+		for i, lhs := range n.Lhs {
+			id, ok := lhs.(*ast.Ident)
+			if !ok || id.Name == "_" || !v.isError(id) {
+				continue
+			}
+			// Discarding a variable declared by := would leave its uses undefined
+			if n.Tok == token.DEFINE && v.Info.Uses[id] == nil {
+				continue
+			}
+			v.replace(n, id.Pos(), &n.Lhs[i], &ast.Ident{NamePos: id.Pos(), Name: "_"})
+		}
+	case *ast.CallExpr:
+		if len(n.Args) != 2 {
+			break
+		}
+		switch errorsFunc(v.Info, n.Fun) {
+		case "Is":
+			// The target is checked for wrapping the error instead of the other way around
+			x, y := n.Args[0], n.Args[1]
+			v.add(n, n.Pos(), func() { n.Args[0], n.Args[1] = y, x }, func() { n.Args[0], n.Args[1] = x, y })
+		case "As":
+			// No error is ever found to match the target
+			v.replace(n, n.Args[0].Pos(), &n.Args[0], &ast.Ident{NamePos: n.Args[0].Pos(), Name: "nil"})
+		}
+	}
+	return v
+}
+
+// isErrCheck reports whether cond compares an error with nil using !=
+func (v *ErrorVisitor) isErrCheck(cond ast.Expr) bool {
+	exp, ok := cond.(*ast.BinaryExpr)
+	if !ok || exp.Op != token.NEQ {
+		return false
+	}
+	return v.isError(exp.X) && isNil(v.Info, exp.Y) || isNil(v.Info, exp.X) && v.isError(exp.Y)
+}
+
+// isError reports whether the type of x is error, or a pointer that implements error
+func (v *ErrorVisitor) isError(x ast.Expr) bool {
+	if v.Info == nil {
+		return false
+	}
+	tv, ok := v.Info.Types[x]
+	if !ok {
+		// Identifiers on the left of an assignment only have an object
+		id, isIdent := x.(*ast.Ident)
+		if !isIdent {
+			return false
+		}
+		obj := v.Info.ObjectOf(id)
+		if obj == nil {
+			return false
+		}
+		tv.Type = obj.Type()
+	}
+	if tv.Type == nil {
+		return false
+	}
+	errorType := types.Universe.Lookup("error").Type()
+	if _, ok := tv.Type.Underlying().(*types.Pointer); ok {
+		return types.Implements(tv.Type, errorType.Underlying().(*types.Interface))
+	}
+	return types.Identical(tv.Type, errorType)
+}
+
+// replace records a mutation that replaces the expression pointed to by x, which
+// is part of node, with repl. The mutant is described by node before and after.
+func (v *ErrorVisitor) replace(node ast.Node, pos token.Pos, x *ast.Expr, repl ast.Expr) {
+	orig := *x
+	v.add(node, pos, func() { *x = repl }, func() { *x = orig })
+}
+
+// add records a mutation of node made by apply
+func (v *ErrorVisitor) add(node ast.Node, pos token.Pos, apply, revert func()) {
+	v.Mutants = append(v.Mutants, nodeMutant("error", node, pos, apply, revert))
+}
+
+// nodeMutant returns a mutant which modifies node using apply and revert,
+// described by the source of node before and after the mutation
+func nodeMutant(category string, node ast.Node, pos token.Pos, apply, revert func()) Mutant {
+	m := Mutant{Pos: pos, Category: category, Original: nodeString(node), Apply: apply, Revert: revert}
+	apply()
+	m.Replacement = nodeString(node)
+	revert()
+	return m
+}
+
+// nodeString returns the first line of the source representation of node
+func nodeString(node ast.Node) string {
+	switch n := node.(type) {
+	case ast.Stmt:
+		return stmtString(n)
+	case ast.Expr:
+		return types.ExprString(n)
+	}
+	return ""
+}
+
+// isNil reports whether x is the predeclared nil
+func isNil(info *types.Info, x ast.Expr) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == "nil" && isUniverse(info, id)
+}
+
+// errorsFunc returns the name of the function of the errors package called by
+// fun, or "" if fun isn't a function of the errors package
+func errorsFunc(info *types.Info, fun ast.Expr) string {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if info != nil {
+		if pkg, ok := info.Uses[id].(*types.PkgName); ok && pkg.Imported().Path() == "errors" {
+			return sel.Sel.Name
+		}
+		if info.Uses[id] != nil {
+			return ""
+		}
+	}
+	if id.Name != "errors" {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+func errorMutants(file *ast.File, info *types.Info) []Mutant {
+	v := ErrorVisitor{Info: info}
+	if info != nil {
+		v.uses = countUses(info, file)
+	}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -0,0 +1,144 @@
+package mutator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// Filter selects mutations by the name of the file and function they appear in,
+// and by the size and visibility of the function. Patterns use the syntax of
+// filepath.Match.
+type Filter struct {
+	// Include, if non-empty, restricts mutations to files or functions matching one of its patterns
+	Include []string
+
+	// Exclude skips mutations in files or functions matching one of its patterns
+	Exclude []string
+
+	// MinFuncLines, if positive, skips mutations in functions spanning fewer lines,
+	// such as getters and String methods
+	MinFuncLines int
+
+	// OnlyExported skips mutations in unexported functions and in methods of unexported types
+	OnlyExported bool
+}
+
+// ParseFilterPatterns splits a comma-separated list of patterns, checking that each is valid
+func ParseFilterPatterns(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	patterns := strings.Split(s, ",")
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", p, err)
+		}
+	}
+	return patterns, nil
+}
+
+// Match reports whether a mutation in the given file and function should be tested.
+// fn is empty for mutations outside of functions.
+func (f *Filter) Match(filename, fn string) bool {
+	filename = filepath.Base(filename)
+	if matchAny(f.Exclude, filename, fn) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, filename, fn)
+}
+
+func matchAny(patterns []string, names ...string) bool {
+	for _, p := range patterns {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if ok, _ := filepath.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchFunc reports whether mutations in fn, which spans the given number of
+// lines, should be tested according to MinFuncLines and OnlyExported. fn is nil
+// for mutations outside of functions, which are skipped if either is set.
+func (f *Filter) MatchFunc(fn *ast.FuncDecl, lines int) bool {
+	if f.MinFuncLines <= 0 && !f.OnlyExported {
+		return true
+	}
+	if fn == nil {
+		return false
+	}
+	if f.OnlyExported {
+		if !fn.Name.IsExported() {
+			return false
+		}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 && !ast.IsExported(recvName(fn.Recv.List[0].Type)) {
+			return false
+		}
+	}
+	return lines >= f.MinFuncLines
+}
+
+// apply returns the mutations in file that match the filter
+func (f *Filter) apply(fset *token.FileSet, file *ast.File, mutants []Mutant) []Mutant {
+	filename := fset.File(file.Pos()).Name()
+	var matched []Mutant
+	for _, m := range mutants {
+		fn := enclosingDecl(file, m.Pos)
+		lines := 0
+		if fn != nil {
+			lines = fset.Position(fn.End()).Line - fset.Position(fn.Pos()).Line + 1
+		}
+		if f.Match(filename, funcName(fn)) && f.MatchFunc(fn, lines) {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// enclosingDecl returns the function declaration in file containing pos, or nil
+func enclosingDecl(file *ast.File, pos token.Pos) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Pos() <= pos && pos < fn.End() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// enclosingFunc returns the name of the function declaration in file containing pos
+func enclosingFunc(file *ast.File, pos token.Pos) string {
+	return funcName(enclosingDecl(file, pos))
+}
+
+// funcName returns the name of fn, or "" if it's nil. Methods are named Type.Method.
+func funcName(fn *ast.FuncDecl) string {
+	if fn == nil {
+		return ""
+	}
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	return recvName(fn.Recv.List[0].Type) + "." + fn.Name.Name
+}
+
+// recvName returns the name of the type of a method receiver
+func recvName(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.StarExpr:
+		return recvName(t.X)
+	case *ast.IndexExpr:
+		return recvName(t.X)
+	case *ast.IndexListExpr:
+		return recvName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		filename string
+		fn       string
+		want     bool
+	}{
+		{"no patterns", Filter{}, "/src/p/a.go", "F", true},
+		{"included file", Filter{Include: []string{"a.go"}}, "/src/p/a.go", "F", true},
+		{"included function", Filter{Include: []string{"F*"}}, "/src/p/a.go", "Foo", true},
+		{"included method", Filter{Include: []string{"T.*"}}, "/src/p/a.go", "T.M", true},
+		{"not included", Filter{Include: []string{"b.go", "G"}}, "/src/p/a.go", "F", false},
+		{"outside functions", Filter{Include: []string{"F"}}, "/src/p/a.go", "", false},
+		{"excluded file", Filter{Exclude: []string{"*_gen.go"}}, "/src/p/a_gen.go", "F", false},
+		{"excluded function", Filter{Exclude: []string{"String"}}, "/src/p/a.go", "String", false},
+		{"not excluded", Filter{Exclude: []string{"G"}}, "/src/p/a.go", "F", true},
+		// Exclusion takes precedence over inclusion, whichever name they match
+		{"included file, excluded function", Filter{Include: []string{"a.go"}, Exclude: []string{"F"}}, "/src/p/a.go", "F", false},
+		{"included function, excluded file", Filter{Include: []string{"F"}, Exclude: []string{"a.go"}}, "/src/p/a.go", "F", false},
+		{"included and excluded", Filter{Include: []string{"F"}, Exclude: []string{"F"}}, "/src/p/a.go", "F", false},
+	}
+	for _, test := range tests {
+		if got := test.filter.Match(test.filename, test.fn); got != test.want {
+			t.Errorf("%s: Match(%q, %q) = %v, want %v", test.name, test.filename, test.fn, got, test.want)
+		}
+	}
+}
+
+func TestParseFilterPatterns(t *testing.T) {
+	patterns, err := ParseFilterPatterns("a.go,T.*")
+	if err != nil || len(patterns) != 2 {
+		t.Errorf("got patterns %q and error %v", patterns, err)
+	}
+	if _, err := ParseFilterPatterns("[a"); err == nil {
+		t.Error("got no error for an invalid pattern")
+	}
+}
+
+func TestFilterFunctions(t *testing.T) {
+	src := `package p
+
+var v = 1 + 2
+
+func Short() int { return 1 + 2 }
+
+func Long(a, b int) int {
+	c := a + b
+	return c + 1
+}
+
+func unexported(a int) int {
+	a = a + 1
+	return a + 1
+}
+
+type T struct{}
+
+func (T) Method(a int) int {
+	a = a + 1
+	return a + 1
+}
+
+type t struct{}
+
+func (*t) Method(a int) int {
+	a = a + 1
+	return a + 1
+}
+`
+	tests := []struct {
+		name   string
+		filter Filter
+		want   map[string]int
+	}{
+		{"none", Filter{}, map[string]int{"": 1, "Short": 1, "Long": 2, "unexported": 2, "T.Method": 2, "t.Method": 2}},
+		{"min lines", Filter{MinFuncLines: 3}, map[string]int{"Long": 2, "unexported": 2, "T.Method": 2, "t.Method": 2}},
+		{"only exported", Filter{OnlyExported: true}, map[string]int{"Short": 1, "Long": 2, "T.Method": 2}},
+		{"both", Filter{MinFuncLines: 4, OnlyExported: true}, map[string]int{"Long": 2, "T.Method": 2}},
+	}
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mutants := FindMutants(file, nil, map[string]bool{"arithmetic": true})
+		got := make(map[string]int)
+		for _, m := range test.filter.apply(fset, file, mutants) {
+			// Each + has a single replacement
+			got[enclosingFunc(file, m.Pos)]++
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got mutants in %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for fn, n := range test.want {
+			if got[fn] != n {
+				t.Errorf("%s: got %d mutants in %q, want %d", test.name, got[fn], fn, n)
+			}
+		}
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	_, file, _ := checkSource(t, `package p
+
+type G[T any] struct{}
+
+func (g *G[T]) M() {}
+
+func F() {}
+`)
+	var got []string
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			got = append(got, funcName(fn))
+		}
+	}
+	if len(got) != 2 || got[0] != "G.M" || got[1] != "F" {
+		t.Errorf("got names %q, want [G.M F]", got)
+	}
+	if name := funcName(nil); name != "" {
+		t.Errorf("got name %q outside functions, want none", name)
+	}
+}
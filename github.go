@@ -0,0 +1,49 @@
+package mutator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GitHubReporter collects surviving mutants and writes them as GitHub Actions
+// workflow commands, which annotate the lines of the mutants in the diff of a
+// pull request
+type GitHubReporter struct {
+	// BaseDir is the directory file names are made relative to, which should be
+	// the root of the repository
+	BaseDir string
+
+	results []Result
+}
+
+func (r *GitHubReporter) Report(result Result) {
+	if result.Outcome == Survived {
+		r.results = append(r.results, result)
+	}
+}
+
+// Write writes a warning for each surviving mutant reported so far to w
+func (r *GitHubReporter) Write(w io.Writer) error {
+	for _, result := range r.results {
+		m := result.Mutant
+		_, err := fmt.Fprintf(w, "::warning file=%s,line=%d,col=%d,title=%s::%s\n",
+			escapeProperty(relPath(r.BaseDir, result.Position.Filename)), result.Position.Line, result.Position.Column,
+			escapeProperty("Surviving mutant ("+m.Category+")"),
+			escapeData(fmt.Sprintf("Surviving mutant: %s -> %s", m.Original, m.Replacement)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,173 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// History is a file recording the results of runs, so that scores can be followed
+// over time. Each run is stored as a JSON object on its own line, which lets new
+// runs be appended without rewriting the file.
+type History struct {
+	// Path is the name of the history file
+	Path string
+}
+
+// RunRecord is the record of a single run in the history
+type RunRecord struct {
+	// Time is when the run started
+	Time time.Time `json:"time"`
+
+	// Commit is the git commit that was tested, if known, and Dirty reports
+	// whether the working tree had uncommitted changes
+	Commit string `json:"commit,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+
+	// Duration is how long the whole run took
+	Duration time.Duration `json:"duration"`
+
+	Mutants []MutantRecord `json:"mutants"`
+}
+
+// MutantRecord is the outcome of a mutant in a recorded run
+type MutantRecord struct {
+	Package     string        `json:"package"`
+	ID          string        `json:"id"`
+	File        string        `json:"file"`
+	Line        int           `json:"line"`
+	Column      int           `json:"column"`
+	Category    string        `json:"category"`
+	Original    string        `json:"original"`
+	Replacement string        `json:"replacement"`
+	Outcome     Outcome       `json:"outcome"`
+	KilledBy    string        `json:"killed_by,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	Diff        string        `json:"diff,omitempty"`
+}
+
+// Key identifies the mutant so it can be matched with the same mutant in other runs
+func (m MutantRecord) Key() string {
+	return fmt.Sprintf("%s %s %s %s -> %s", m.Package, m.ID, m.Category, m.Original, m.Replacement)
+}
+
+// Result returns the result the record was made from, apart from the test output
+// and the functions applying the mutant, so it can be passed to a Reporter
+func (m MutantRecord) Result() Result {
+	return Result{
+		Mutant:   Mutant{Category: m.Category, Original: m.Original, Replacement: m.Replacement},
+		Package:  m.Package,
+		Position: token.Position{Filename: m.File, Line: m.Line, Column: m.Column},
+		ID:       m.ID,
+		Outcome:  m.Outcome,
+		KilledBy: m.KilledBy,
+		Duration: m.Duration,
+		Diff:     m.Diff,
+	}
+}
+
+// Report adds result to the run
+func (r *RunRecord) Report(result Result) {
+	r.Mutants = append(r.Mutants, MutantRecord{
+		Package:     result.Package,
+		ID:          result.ID,
+		File:        result.Position.Filename,
+		Line:        result.Position.Line,
+		Column:      result.Position.Column,
+		Category:    result.Mutant.Category,
+		Original:    result.Mutant.Original,
+		Replacement: result.Mutant.Replacement,
+		Outcome:     result.Outcome,
+		KilledBy:    result.KilledBy,
+		Duration:    result.Duration,
+		Diff:        result.Diff,
+	})
+}
+
+// Replay passes the result of each mutant in the run to reporter
+func (r *RunRecord) Replay(reporter Reporter) {
+	for _, m := range r.Mutants {
+		reporter.Report(m.Result())
+	}
+}
+
+// Summary counts the outcomes of the mutants in the run
+func (r *RunRecord) Summary() Summary {
+	s := make(Summary)
+	for _, m := range r.Mutants {
+		s[m.Outcome]++
+	}
+	return s
+}
+
+// Regressions returns the mutants that were killed in from but survived in to
+func Regressions(from, to *RunRecord) []MutantRecord {
+	killed := make(map[string]bool)
+	for _, m := range from.Mutants {
+		if m.Outcome == Killed {
+			killed[m.Key()] = true
+		}
+	}
+	var regressed []MutantRecord
+	for _, m := range to.Mutants {
+		if m.Outcome == Survived && killed[m.Key()] {
+			regressed = append(regressed, m)
+		}
+	}
+	return regressed
+}
+
+// Append adds run to the end of the history, creating the file if needed
+func (h *History) Append(run *RunRecord) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the runs in the history, oldest first
+func (h *History) Load() ([]RunRecord, error) {
+	f, err := os.Open(h.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []RunRecord
+	dec := json.NewDecoder(f)
+	for {
+		var run RunRecord
+		if err := dec.Decode(&run); err == io.EOF {
+			return runs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: run %d: %s", h.Path, len(runs)+1, err)
+		}
+		runs = append(runs, run)
+	}
+}
+
+// GitCommit returns the commit checked out in the current directory and whether
+// the working tree has uncommitted changes. The commit is empty if it can't be
+// determined, for example outside of a git repository.
+func GitCommit() (commit string, dirty bool) {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output()
+	return strings.TrimSpace(string(out)), err == nil && len(status) > 0
+}
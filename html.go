@@ -0,0 +1,92 @@
+package mutator
+
+import (
+	"html/template"
+	"io"
+	"sort"
+)
+
+// HTMLReporter collects results and writes them as an HTML page, with a table of
+// mutants for each package and the diffs of those that survived.
+type HTMLReporter struct {
+	results []Result
+}
+
+func (r *HTMLReporter) Report(result Result) {
+	r.results = append(r.results, result)
+}
+
+type htmlPackage struct {
+	Name    string
+	Summary Summary
+	Results []Result
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent":    func(s Summary) float64 { return 100 * s.Score() },
+	"killed":     func(s Summary) int { return s[Killed] },
+	"survived":   func(s Summary) int { return s[Survived] },
+	"isSurvivor": func(r Result) bool { return r.Outcome == Survived },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mutation report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { text-align: left; padding: 0.25em 1em; border-bottom: 1px solid #ddd; vertical-align: top; }
+code, pre { font-family: monospace; }
+.killed { color: #080; }
+.survived { color: #b00; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Mutation report</h1>
+<p>{{.Summary.Total}} mutations: {{killed .Summary}} killed, {{survived .Summary}} survived.
+Mutation score: {{printf "%.1f" (percent .Summary)}}%</p>
+{{range .Packages}}
+<h2>{{.Name}}</h2>
+<p>{{.Summary.Total}} mutations, score {{printf "%.1f" (percent .Summary)}}%</p>
+<table>
+<tr><th>Mutant</th><th>Category</th><th>Change</th><th>Outcome</th></tr>
+{{range .Results}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Mutant.Category}}</td>
+<td><code>{{.Mutant.Original}}</code> &rarr; <code>{{.Mutant.Replacement}}</code>
+{{if and (isSurvivor .) .Diff}}<details><summary>diff</summary><pre>{{.Diff}}</pre></details>{{end}}</td>
+<td class="{{.Outcome}}">{{.Outcome}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// Write writes the HTML report of the results reported so far to w
+func (r *HTMLReporter) Write(w io.Writer) error {
+	packages := make(map[string]*htmlPackage)
+	var names []string
+	summary := make(Summary)
+	for _, result := range r.results {
+		pkg, ok := packages[result.Package]
+		if !ok {
+			pkg = &htmlPackage{Name: result.Package, Summary: make(Summary)}
+			packages[result.Package] = pkg
+			names = append(names, result.Package)
+		}
+		pkg.Summary[result.Outcome]++
+		pkg.Results = append(pkg.Results, result)
+		summary[result.Outcome]++
+	}
+
+	sort.Strings(names)
+	data := struct {
+		Summary  Summary
+		Packages []*htmlPackage
+	}{Summary: summary}
+	for _, name := range names {
+		data.Packages = append(data.Packages, packages[name])
+	}
+	return htmlTemplate.Execute(w, data)
+}
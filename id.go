@@ -0,0 +1,75 @@
+package mutator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// setIDs sets the ID of each mutant of file, which must be all those found by
+// FindMutants in the order it returned them. IDs are derived from the name of
+// the file and of the enclosing function, the category, the change made and the
+// source of the mutated node, rather than from its position, so that they don't
+// change when code elsewhere does. Mutants that are otherwise the same are
+// numbered in the order they appear.
+func setIDs(fset *token.FileSet, file *ast.File, mutants []Mutant) {
+	tf := fset.File(file.Pos())
+	src, _ := ioutil.ReadFile(tf.Name())
+
+	seen := make(map[string]int)
+	for i, m := range mutants {
+		var text []byte
+		if node := mutatedNode(file, m.Pos); node != nil {
+			start, end := tf.Offset(node.Pos()), tf.Offset(node.End())
+			if start <= end && end <= len(src) {
+				text = bytes.Join(bytes.Fields(src[start:end]), []byte(" "))
+			}
+		}
+		key := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s", filepath.Base(tf.Name()), enclosingFunc(file, m.Pos), m.Category, m.Original, m.Replacement, text)
+		seen[key]++
+		h := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, seen[key])))
+		mutants[i].ID = hex.EncodeToString(h[:6])
+	}
+}
+
+// mutatedNode returns the expression or statement of file mutated at pos: the
+// outermost one starting at pos, or if none does, such as for the operator of
+// a binary expression, the innermost one containing it
+func mutatedNode(file *ast.File, pos token.Pos) ast.Node {
+	var start, within ast.Node
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil || pos < node.Pos() || pos >= node.End() {
+			return false
+		}
+		switch node.(type) {
+		case ast.Expr, ast.Stmt:
+			if start == nil && node.Pos() == pos {
+				start = node
+			}
+			within = node
+		}
+		return true
+	})
+	if start != nil {
+		return start
+	}
+	return within
+}
+
+// matchID reports whether id identifies the mutant m at pos, either by its ID
+// or by its position as returned by MutationID
+func matchID(id string, m Mutant, pos token.Position) bool {
+	return id == m.ID || id == MutationID(pos)
+}
+
+// MutationID returns the position of the mutant at pos as shown in reports,
+// which names it along with its ID
+func MutationID(pos token.Position) string {
+	pos.Filename = filepath.Base(pos.Filename)
+	return pos.String()
+}
@@ -0,0 +1,101 @@
+package mutator
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// mutantIDs writes src to the named file in dir and returns the IDs of its
+// arithmetic mutants by their change and position
+func mutantIDs(t *testing.T, dir, name, src string) map[string]string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutants := FindMutants(file, nil, map[string]bool{"arithmetic": true})
+	setIDs(fset, file, mutants)
+
+	ids := make(map[string]string)
+	for _, m := range mutants {
+		pos := fset.Position(m.Pos)
+		ids[fmt.Sprintf("%d:%d %s -> %s", pos.Line, pos.Column, m.Original, m.Replacement)] = m.ID
+	}
+	return ids
+}
+
+func TestIDsIgnoreUnrelatedLines(t *testing.T) {
+	dir := t.TempDir()
+	before := mutantIDs(t, dir, "a.go", `package p
+
+func F(a, b int) int {
+	return a + b
+}
+`)
+	after := mutantIDs(t, dir, "a.go", `package p
+
+// G is new
+func G() {}
+
+func F(a, b int) int {
+
+	return a + b
+}
+`)
+
+	moved := map[string]string{"4:11 + -> -": "8:11 + -> -"}
+	if len(before) != len(moved) {
+		t.Fatalf("got mutants %v, want %d", before, len(moved))
+	}
+	for change, id := range before {
+		if got := after[moved[change]]; got != id {
+			t.Errorf("ID of %s changed from %s to %s", change, id, got)
+		}
+	}
+}
+
+func TestIDsDistinguishIdenticalMutants(t *testing.T) {
+	dir := t.TempDir()
+	a := mutantIDs(t, dir, "a.go", `package p
+
+func F(a, b int) int {
+	x := a + b
+	y := a + b
+	return x * y
+}
+
+func init() {
+	n = n + 1
+}
+
+var n int
+`)
+	b := mutantIDs(t, dir, "b.go", `package p
+
+func init() {
+	n = n + 1
+}
+`)
+
+	seen := make(map[string]string)
+	for _, ids := range []map[string]string{a, b} {
+		for change, id := range ids {
+			if other, ok := seen[id]; ok {
+				t.Errorf("mutants %s and %s have the same ID %s", other, change, id)
+			}
+			seen[id] = change
+		}
+	}
+	if len(seen) != len(a)+len(b) {
+		t.Errorf("got %d distinct IDs for %d mutants", len(seen), len(a)+len(b))
+	}
+}
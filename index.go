@@ -0,0 +1,150 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// IndexVisitor finds index and slice expressions whose bounds can be moved by
+// one: a[i] becomes a[i-1] or a[i+1], and a[i:j] becomes a[i+1:j] or a[i:j-1],
+// with missing bounds taken to be 0 and len(a). Only arrays, slices and strings
+// are mutated, so nothing is mutated without type information, and constant
+// bounds are only moved where they stay valid.
+type IndexVisitor struct {
+	// Info holds the type information of the file
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+}
+
+func (v *IndexVisitor) Visit(node ast.Node) ast.Visitor {
+	if v.Info == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *ast.IndexExpr:
+		length, ok := v.indexable(n.X)
+		if !ok {
+			break
+		}
+		index, isConst := v.constInt(n.Index)
+		if !isConst || index > 0 {
+			v.replace(n, n.Lbrack, &n.Index, offset(n.Index, token.SUB))
+		}
+		if !isConst || length < 0 || index+1 < length {
+			v.replace(n, n.Lbrack, &n.Index, offset(n.Index, token.ADD))
+		}
+	case *ast.SliceExpr:
+		if _, ok := v.indexable(n.X); !ok {
+			break
+		}
+		low, lowConst := int64(0), true
+		if n.Low != nil {
+			low, lowConst = v.constInt(n.Low)
+		}
+		high, highConst := v.constInt(n.High)
+
+		// The low bound may not pass a constant high bound
+		if !lowConst || !highConst || low+1 <= high {
+			if n.Low == nil {
+				v.replace(n, n.Lbrack, &n.Low, &ast.BasicLit{ValuePos: n.Lbrack + 1, Kind: token.INT, Value: "1"})
+			} else {
+				v.replace(n, n.Lbrack, &n.Low, offset(n.Low, token.ADD))
+			}
+		}
+
+		// A missing high bound is only written out when its length is cheap to evaluate again
+		switch {
+		case n.High != nil && (!highConst || high > low):
+			v.replace(n, n.Lbrack, &n.High, offset(n.High, token.SUB))
+		case n.High == nil && simple(n.X):
+			length := &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{n.X}}
+			v.replace(n, n.Lbrack, &n.High, offset(length, token.SUB))
+		}
+	}
+	return v
+}
+
+// indexable reports whether x is an array, slice or string that isn't constant,
+// returning the length of arrays or -1 for the others
+func (v *IndexVisitor) indexable(x ast.Expr) (int64, bool) {
+	tv, ok := v.Info.Types[x]
+	if !ok || tv.Type == nil || tv.Value != nil {
+		return 0, false
+	}
+	typ := tv.Type.Underlying()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem().Underlying()
+		if _, ok := typ.(*types.Array); !ok {
+			return 0, false
+		}
+	}
+	switch t := typ.(type) {
+	case *types.Array:
+		return t.Len(), true
+	case *types.Slice:
+		return -1, true
+	case *types.Basic:
+		return -1, t.Info()&types.IsString != 0
+	}
+	return 0, false
+}
+
+// constInt returns the value of x if it's an integer constant
+func (v *IndexVisitor) constInt(x ast.Expr) (int64, bool) {
+	if x == nil {
+		return 0, false
+	}
+	tv, ok := v.Info.Types[x]
+	if !ok || tv.Value == nil {
+		return 0, false
+	}
+	return constant.Int64Val(constant.ToInt(tv.Value))
+}
+
+// replace records a mutation of the index or slice expression node, at pos, that
+// replaces the bound pointed to by x with repl
+func (v *IndexVisitor) replace(node ast.Expr, pos token.Pos, x *ast.Expr, repl ast.Expr) {
+	orig := *x
+	m := Mutant{
+		Pos:      pos,
+		Category: "index",
+		Original: types.ExprString(node),
+		Apply:    func() { *x = repl },
+		Revert:   func() { *x = orig },
+	}
+	m.Apply()
+	m.Replacement = types.ExprString(node)
+	m.Revert()
+	v.Mutants = append(v.Mutants, m)
+}
+
+// offset returns x plus or minus one, according to op
+func offset(x ast.Expr, op token.Token) ast.Expr {
+	if _, ok := x.(*ast.BinaryExpr); ok {
+		x = &ast.ParenExpr{X: x}
+	}
+	return &ast.BinaryExpr{X: x, OpPos: x.End(), Op: op, Y: &ast.BasicLit{ValuePos: x.End(), Kind: token.INT, Value: "1"}}
+}
+
+// simple reports whether x is a variable or field, which can be evaluated again
+// without side effects
+func simple(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return simple(x.X)
+	}
+	return false
+}
+
+func indexMutants(file *ast.File, info *types.Info) []Mutant {
+	v := IndexVisitor{Info: info}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
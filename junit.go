@@ -0,0 +1,110 @@
+package mutator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// JUnitReporter collects results and writes them as a JUnit XML report, so that
+// CI systems can show them alongside test results. Each mutant is a test case
+// which passes if it was killed and fails if it survived.
+type JUnitReporter struct {
+	results []Result
+}
+
+func (r *JUnitReporter) Report(result Result) {
+	r.results = append(r.results, result)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// Write writes the JUnit report of the results reported so far to w, with a
+// test suite for each package
+func (r *JUnitReporter) Write(w io.Writer) error {
+	suites := make(map[string]*junitTestSuite)
+	total := make(map[string]float64)
+	var names []string
+	for _, result := range r.results {
+		suite, ok := suites[result.Package]
+		if !ok {
+			suite = &junitTestSuite{Name: result.Package}
+			suites[result.Package] = suite
+			names = append(names, result.Package)
+		}
+
+		tc := junitTestCase{
+			ClassName: result.Package,
+			Name: fmt.Sprintf("%s %s %s -> %s", result.Name(), result.Mutant.Category,
+				result.Mutant.Original, result.Mutant.Replacement),
+			Time: fmt.Sprintf("%.3f", result.Duration.Seconds()),
+		}
+		switch result.Outcome {
+		case Survived:
+			tc.Failure = &junitMessage{Message: "mutant survived", Body: result.Diff}
+			suite.Failures++
+		case TestError:
+			tc.Error = &junitMessage{Message: "tests could not be run", Body: string(result.Output)}
+			suite.Errors++
+		case BuildError:
+			tc.Skipped = &junitMessage{Message: "mutant did not build"}
+			suite.Skipped++
+		case Equivalent:
+			tc.Skipped = &junitMessage{Message: "mutant is equivalent to the original"}
+			suite.Skipped++
+		case Suppressed:
+			tc.Skipped = &junitMessage{Message: "mutant survived but is accepted in the baseline"}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		total[result.Package] += result.Duration.Seconds()
+	}
+
+	sort.Strings(names)
+	var report junitTestSuites
+	for _, name := range names {
+		suite := suites[name]
+		suite.Time = fmt.Sprintf("%.3f", total[name])
+		report.Suites = append(report.Suites, *suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
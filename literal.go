@@ -0,0 +1,81 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// LiteralVisitor finds boolean and integer literals that can be perturbed
+type LiteralVisitor struct {
+	// Info is used to skip identifiers that shadow true and false, if non-nil
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+
+	// divisors is the set of expressions used as the right operand of a division
+	divisors map[ast.Expr]bool
+}
+
+func (v *LiteralVisitor) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.BinaryExpr:
+		if n.Op == token.QUO || n.Op == token.REM {
+			v.divisor(n.Y)
+		}
+	case *ast.AssignStmt:
+		if n.Tok == token.QUO_ASSIGN || n.Tok == token.REM_ASSIGN {
+			v.divisor(n.Rhs[0])
+		}
+	case *ast.Ident:
+		if (n.Name == "true" || n.Name == "false") && isUniverse(v.Info, n) {
+			v.replace(n.Pos(), &n.Name, strconv.FormatBool(n.Name != "true"))
+		}
+	case *ast.BasicLit:
+		if n.Kind != token.INT {
+			break
+		}
+		val, err := strconv.ParseUint(n.Value, 0, 64)
+		if err != nil {
+			break
+		}
+		v.replace(n.Pos(), &n.Value, strconv.FormatUint(val+1, 10))
+		// Negative replacements can't be represented as a single literal
+		if val > 0 {
+			v.replace(n.Pos(), &n.Value, strconv.FormatUint(val-1, 10))
+			if val > 1 && !v.divisors[n] {
+				v.replace(n.Pos(), &n.Value, "0")
+			}
+		}
+	}
+	return v
+}
+
+// divisor marks x as the right operand of a division, which must not become zero
+func (v *LiteralVisitor) divisor(x ast.Expr) {
+	if v.divisors == nil {
+		v.divisors = make(map[ast.Expr]bool)
+	}
+	v.divisors[x] = true
+}
+
+// replace records a mutation that replaces the text pointed to by s with repl
+func (v *LiteralVisitor) replace(pos token.Pos, s *string, repl string) {
+	orig := *s
+	v.Mutants = append(v.Mutants, Mutant{
+		Pos:         pos,
+		Category:    "literal",
+		Original:    orig,
+		Replacement: repl,
+		Apply:       func() { *s = repl },
+		Revert:      func() { *s = orig },
+	})
+}
+
+func literalMutants(file *ast.File, info *types.Info) []Mutant {
+	v := LiteralVisitor{Info: info}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -0,0 +1,46 @@
+package mutator
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Level is the verbosity of a Logger
+type Level int
+
+const (
+	// LevelQuiet logs nothing, leaving only the summaries printed by the caller
+	LevelQuiet Level = iota
+
+	// LevelNormal logs a line for each mutant and warnings
+	LevelNormal
+
+	// LevelVerbose also logs the diff and test output of each mutant, and
+	// diagnostic messages describing what the runner is doing
+	LevelVerbose
+)
+
+// Logger writes the messages at or below its level to W. A nil Logger logs nothing.
+// It's safe for concurrent use.
+type Logger struct {
+	W     io.Writer
+	Level Level
+
+	mu sync.Mutex
+}
+
+// Enabled reports whether messages at level are logged
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level <= l.Level
+}
+
+// Printf logs a message at level
+func (l *Logger) Printf(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.W, format, args...)
+}
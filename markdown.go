@@ -0,0 +1,71 @@
+package mutator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxMarkdownSurvivors limits the surviving mutants listed in a Markdown
+// summary, which keeps it within the size of a pull request comment
+const maxMarkdownSurvivors = 100
+
+// MarkdownReporter collects results and writes a summary of them as Markdown,
+// suitable for posting as a pull request comment: a table of the outcomes
+// followed by a table of the surviving mutants.
+type MarkdownReporter struct {
+	// BaseDir is the directory file names are made relative to, if non-empty
+	BaseDir string
+
+	summary   Summary
+	survivors []Result
+}
+
+func (r *MarkdownReporter) Report(result Result) {
+	if r.summary == nil {
+		r.summary = make(Summary)
+	}
+	r.summary[result.Outcome]++
+	if result.Outcome == Survived {
+		r.survivors = append(r.survivors, result)
+	}
+}
+
+// Write writes the summary of the results reported so far to w
+func (r *MarkdownReporter) Write(w io.Writer) error {
+	s := r.summary
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Mutation testing\n\n")
+	fmt.Fprintf(&b, "| Mutations | Killed | Survived | Build errors | Test errors | Equivalent | Suppressed | Score |\n")
+	fmt.Fprintf(&b, "|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d | %d | %.1f%% |\n",
+		s.Total(), s[Killed], s[Survived], s[BuildError], s[TestError], s[Equivalent], s[Suppressed], 100*s.Score())
+
+	if len(r.survivors) > 0 {
+		fmt.Fprintf(&b, "\n#### Surviving mutants\n\n")
+		fmt.Fprintf(&b, "| Location | Category | Mutation |\n")
+		fmt.Fprintf(&b, "|---|---|---|\n")
+		sortResults(r.survivors)
+		for i, result := range r.survivors {
+			if i == maxMarkdownSurvivors {
+				fmt.Fprintf(&b, "\n…and %d more.\n", len(r.survivors)-i)
+				break
+			}
+			m := result.Mutant
+			fmt.Fprintf(&b, "| %s | %s | %s → %s |\n", markdownCode(fmt.Sprintf("%s:%d",
+				relPath(r.BaseDir, result.Position.Filename), result.Position.Line)), m.Category,
+				markdownCode(m.Original), markdownCode(m.Replacement))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCode formats s as inline code within a table cell
+func markdownCode(s string) string {
+	s = strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
@@ -1,204 +1,219 @@
-package main
+// Package mutator implements mutation testing for Go packages.
+//
+// Mutants are small changes to the source of a package, such as swapping
+// a comparison operator or removing a statement. Each mutant is tested by
+// running the package's tests against it: a good test suite fails, killing
+// the mutant, while a mutant that survives points at untested behaviour.
+//
+// The results of runs can be recorded in a History to follow scores over time.
+// It's a file of JSON lines rather than an embedded database such as SQLite or
+// BoltDB, which keeps the package free of dependencies outside the standard
+// library and lets the history be read with ordinary tools.
+package mutator
 
 import (
-	"bytes"
-	"flag"
-	"fmt"
 	"go/ast"
-	"go/build"
-	"go/parser"
-	"go/printer"
 	"go/token"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
+	"go/types"
+	"sort"
+	"sync"
 )
 
-type mutation struct {
-	op       token.Token
+// operator describes how a binary operator is mutated
+type operator struct {
 	category string
+
+	// replacements are the operators substituted for it, each in its own mutant
+	replacements []token.Token
 }
 
-var operators = map[token.Token]mutation{
+// operators holds the mutations of each binary operator. An operator replaced
+// by another is also a replacement of that operator, so that mutations can be
+// undone and both directions of a mistake are tested.
+var operators = map[token.Token]operator{
 	// Comparisons
-	token.EQL: {token.NEQ, "comparison"},
-	token.LSS: {token.GEQ, "comparison"},
-	token.GTR: {token.LEQ, "comparison"},
-	token.NEQ: {token.EQL, "comparison"},
-	token.LEQ: {token.GTR, "comparison"},
-	token.GEQ: {token.LSS, "comparison"},
+	token.EQL: {"comparison", []token.Token{token.NEQ}},
+	token.LSS: {"comparison", []token.Token{token.GEQ}},
+	token.GTR: {"comparison", []token.Token{token.LEQ}},
+	token.NEQ: {"comparison", []token.Token{token.EQL}},
+	token.LEQ: {"comparison", []token.Token{token.GTR}},
+	token.GEQ: {"comparison", []token.Token{token.LSS}},
 
 	// Logical
-	token.LAND: {token.LOR, "logical"},
-	token.LOR:  {token.LAND, "logical"},
+	token.LAND: {"logical", []token.Token{token.LOR}},
+	token.LOR:  {"logical", []token.Token{token.LAND}},
 
 	// Arithmetic
-	token.ADD: {token.SUB, "arithmetic"},
-	token.SUB: {token.ADD, "arithmetic"},
-	token.MUL: {token.QUO, "arithmetic"},
-	token.QUO: {token.MUL, "arithmetic"},
+	token.ADD: {"arithmetic", []token.Token{token.SUB}},
+	token.SUB: {"arithmetic", []token.Token{token.ADD}},
+	token.MUL: {"arithmetic", []token.Token{token.QUO}},
+	token.QUO: {"arithmetic", []token.Token{token.MUL}},
 
 	// Binary
-	token.AND: {token.OR, "binary"},
-	token.OR:  {token.AND, "binary"},
-	token.XOR: {token.AND, "binary"},
-	token.SHL: {token.SHR, "binary"},
-	token.SHR: {token.SHL, "binary"},
+	token.AND:     {"binary", []token.Token{token.OR, token.AND_NOT}},
+	token.OR:      {"binary", []token.Token{token.AND, token.XOR, token.AND_NOT}},
+	token.XOR:     {"binary", []token.Token{token.OR}},
+	token.AND_NOT: {"binary", []token.Token{token.AND, token.OR}},
+	token.SHL:     {"binary", []token.Token{token.SHR}},
+	token.SHR:     {"binary", []token.Token{token.SHL}},
 }
 
-type BinaryExprVisitor struct {
-	// Categories is a set of operator categories to consider for mutation
-	Categories map[string]bool
+// Mutant is a single reversible change to a parsed source file
+type Mutant struct {
+	// Pos is the position of the mutated node
+	Pos token.Pos
 
-	// Exps is a list of binary expressions discovered by the visitor
-	Exps []*ast.BinaryExpr
-}
+	// Category is the category the mutant belongs to
+	Category string
 
-func (v *BinaryExprVisitor) Visit(node ast.Node) ast.Visitor {
-	if exp, ok := node.(*ast.BinaryExpr); ok {
-		if _, ok := operators[exp.Op]; ok && v.Categories[operators[exp.Op].category] {
-			v.Exps = append(v.Exps, exp)
-		}
-	}
-	return v
+	// Original and Replacement describe the code before and after the mutation
+	Original, Replacement string
+
+	// ID identifies the mutant independently of its position. It's set by
+	// Runner.Mutants.
+	ID string
+
+	// Apply modifies the syntax tree and Revert restores it
+	Apply, Revert func()
+
+	// positions holds the position of each mutant applied by a combined mutant
+	positions []token.Pos
 }
 
-func Err(s string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "error: "+s, args...)
+// Mutator finds the mutants of a single category in a file
+type Mutator interface {
+	// Mutants returns the mutants of file. If info is non-nil it holds the
+	// type information of the file's package, and should be used to avoid
+	// mutants that would not compile.
+	Mutants(file *ast.File, info *types.Info) []Mutant
 }
 
-func Errf(s string, args ...interface{}) {
-	Err(s, args...)
-	os.Exit(1)
+// MutatorFunc is an adapter to allow the use of an ordinary function as a Mutator
+type MutatorFunc func(file *ast.File, info *types.Info) []Mutant
+
+func (f MutatorFunc) Mutants(file *ast.File, info *types.Info) []Mutant {
+	return f(file, info)
 }
 
-func main() {
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: mutator [flags] [package] [testflags]\n")
-		flag.PrintDefaults()
-	}
-	categories := flag.String("categories", "comparison,logical,arithmetic,binary",
-		"A comma-separated list of mutation categories to enable. All categories are enabled by default.")
-	flag.Parse()
-
-	pkgPath := flag.Arg(0)
-	if pkgPath == "" {
-		flag.Usage()
-		Errf("must provide a package\n")
-	}
+var mutatorsMu sync.RWMutex
+
+// mutators holds the registered mutators by category
+var mutators = map[string]Mutator{
+	"comparison":          operatorMutator("comparison"),
+	"logical":             operatorMutator("logical"),
+	"arithmetic":          operatorMutator("arithmetic"),
+	"binary":              operatorMutator("binary"),
+	"statement":           MutatorFunc(statementMutants),
+	"literal":             MutatorFunc(literalMutants),
+	"negate-conditionals": MutatorFunc(conditionMutants),
+	"error":               MutatorFunc(errorMutants),
+	"return":              MutatorFunc(returnMutants),
+	"unary":               MutatorFunc(unaryMutants),
+	"index":               MutatorFunc(indexMutants),
+}
 
-	var testFlags []string
-	if flag.NArg() > 1 {
-		testFlags = flag.Args()[1:]
+// Register makes a mutator available under the given category name.
+// It panics if m is nil or a mutator is already registered for category.
+func Register(category string, m Mutator) {
+	mutatorsMu.Lock()
+	defer mutatorsMu.Unlock()
+	if m == nil {
+		panic("mutator: Register mutator is nil")
 	}
-
-	enabledCategories := make(map[string]bool)
-	for _, cat := range strings.Split(*categories, ",") {
-		enabledCategories[cat] = true
+	if _, dup := mutators[category]; dup {
+		panic("mutator: Register called twice for category " + category)
 	}
+	mutators[category] = m
+}
 
-	if err := MutatePackage(pkgPath, testFlags, enabledCategories); err != nil {
-		Errf("%s\n", err)
+// Categories returns the sorted names of the registered mutation categories
+func Categories() []string {
+	mutatorsMu.RLock()
+	defer mutatorsMu.RUnlock()
+	var categories []string
+	for category := range mutators {
+		categories = append(categories, category)
 	}
+	sort.Strings(categories)
+	return categories
 }
 
-func MutatePackage(name string, testFlags []string, enabledCategories map[string]bool) error {
-	pkg, err := build.Import(name, "", 0)
-	if err != nil {
-		return fmt.Errorf("could not import %s: %s", name, err)
-	}
+// operatorMutator swaps the operators of binary expressions and assignments in a category
+type operatorMutator string
 
-	tmpDir, err := ioutil.TempDir("", "mutate")
-	if err != nil {
-		return fmt.Errorf("could not create temporary directory: %s", err)
-	}
+func (category operatorMutator) Mutants(file *ast.File, info *types.Info) []Mutant {
+	categories := map[string]bool{string(category): true}
 
-	fmt.Fprintf(os.Stderr, "using %s as a temporary directory\n", tmpDir)
-	if err := copyDir(pkg.Dir, tmpDir); err != nil {
-		return fmt.Errorf("could not copy package directory: %s", err)
-	}
+	binary := BinaryExprVisitor{Categories: categories, Info: info}
+	ast.Walk(&binary, file)
 
-	for _, f := range pkg.GoFiles {
-		srcFile := filepath.Join(tmpDir, f)
-		if err := MutateFile(srcFile, testFlags, enabledCategories); err != nil {
-			return err
-		}
-	}
-	return nil
-}
+	assign := AssignStmtVisitor{Categories: categories, Info: info}
+	ast.Walk(&assign, file)
 
-func MutationID(pos token.Position) string {
-	pos.Filename = filepath.Base(pos.Filename)
-	return pos.String()
+	return append(binary.Mutants, assign.Mutants...)
 }
 
-func MutateFile(srcFile string, testFlags []string, enabledCategories map[string]bool) error {
-	fset := token.NewFileSet()
+type BinaryExprVisitor struct {
+	// Categories is a set of operator categories to consider for mutation
+	Categories map[string]bool
 
-	file, err := parser.ParseFile(fset, srcFile, nil, parser.ParseComments)
-	if err != nil {
-		return fmt.Errorf("could not parse %s: %s", srcFile, err)
-	}
+	// Info is used to skip mutants that are invalid for the operand types, if non-nil
+	Info *types.Info
 
-	visitor := BinaryExprVisitor{Categories: enabledCategories}
-	ast.Walk(&visitor, file)
-
-	filename := filepath.Base(srcFile)
-	fmt.Fprintf(os.Stderr, "%s has %d mutation sites\n", filename, len(visitor.Exps))
-	for _, exp := range visitor.Exps {
-		err := func() error {
-			oldOp := exp.Op
-			exp.Op = operators[exp.Op].op
-			defer func() {
-				exp.Op = oldOp
-			}()
-
-			if err := printAST(srcFile, fset, file); err != nil {
-				return err
-			}
-
-			args := []string{"test"}
-			args = append(args, testFlags...)
-			cmd := exec.Command("go", args...)
-			cmd.Dir = filepath.Dir(srcFile)
-			output, err := cmd.CombinedOutput()
-			if err == nil {
-				fmt.Fprintf(os.Stderr, "mutation %s did not fail tests\n", MutationID(fset.Position(exp.OpPos)))
-			} else if _, ok := err.(*exec.ExitError); ok {
-				lines := bytes.Split(output, []byte("\n"))
-				lastLine := lines[len(lines)-2]
-				if !bytes.HasPrefix(lastLine, []byte("FAIL")) {
-					fmt.Fprintf(os.Stderr, "mutation %s tests resulted in an error: %s\n", MutationID(fset.Position(exp.OpPos)), lastLine)
-				} else {
-					fmt.Fprintf(os.Stderr, "mutation %s tests failed as expected\n", MutationID(fset.Position(exp.OpPos)))
-				}
-			} else {
-				return fmt.Errorf("mutation %s failed to run tests: %s\n", MutationID(fset.Position(exp.OpPos)), err)
-			}
-			return nil
-		}()
-		if err != nil {
-			return err
-		}
-	}
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+}
 
-	if err := printAST(srcFile, fset, file); err != nil {
-		return err
+func (v *BinaryExprVisitor) Visit(node ast.Node) ast.Visitor {
+	exp, ok := node.(*ast.BinaryExpr)
+	if !ok {
+		return v
 	}
-	return nil
+	m, ok := operators[exp.Op]
+	if !ok || !v.Categories[m.category] {
+		return v
+	}
+	op := exp.Op
+	for _, repl := range m.replacements {
+		if !validOperator(v.Info, exp.X, repl) {
+			continue
+		}
+		repl := repl
+		v.Mutants = append(v.Mutants, Mutant{
+			Pos:         exp.OpPos,
+			Category:    m.category,
+			Original:    op.String(),
+			Replacement: repl.String(),
+			Apply:       func() { exp.Op = repl },
+			Revert:      func() { exp.Op = op },
+		})
+	}
+	return v
 }
 
-func printAST(path string, fset *token.FileSet, node interface{}) error {
-	out, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
-	if err != nil {
-		return fmt.Errorf("could not create file: %s", err)
+// FindMutants returns all the mutants in the enabled categories for the given file,
+// ordered by their position. If info is non-nil it is used to skip mutants that
+// would not compile.
+func FindMutants(file *ast.File, info *types.Info, enabledCategories map[string]bool) []Mutant {
+	mutatorsMu.RLock()
+	defer mutatorsMu.RUnlock()
+
+	var categories []string
+	for category, enabled := range enabledCategories {
+		if _, ok := mutators[category]; ok && enabled {
+			categories = append(categories, category)
+		}
 	}
-	defer out.Close()
+	// Sorting keeps the order of mutants at the same position stable between runs
+	sort.Strings(categories)
 
-	if err := printer.Fprint(out, fset, node); err != nil {
-		return fmt.Errorf("could not print %s: %s", path, err)
+	var mutants []Mutant
+	for _, category := range categories {
+		mutants = append(mutants, mutators[category].Mutants(file, info)...)
 	}
-	return nil
+
+	sort.SliceStable(mutants, func(i, j int) bool {
+		return mutants[i].Pos < mutants[j].Pos
+	})
+	return mutants
 }
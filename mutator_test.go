@@ -0,0 +1,274 @@
+package mutator
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestMutants(t *testing.T) {
+	tests := []struct {
+		name     string
+		category string
+		src      string
+		want     []string
+	}{
+		{
+			name:     "arithmetic",
+			category: "arithmetic",
+			src: `func F(a, b int, x, y float64) (int, float64) {
+	return a + b*2, x / y
+}`,
+			want: []string{"+ -> -", "* -> /", "/ -> *"},
+		},
+		{
+			name:     "arithmetic on strings",
+			category: "arithmetic",
+			src: `func F(a, b string) string {
+	return a + b
+}`,
+		},
+		{
+			name:     "assign",
+			category: "arithmetic",
+			src: `func F(a int, s string) (int, string) {
+	a += 2
+	a *= 3
+	a++
+	s += "x"
+	return a, s
+}`,
+			want: []string{"+= -> -=", "*= -> /=", "++ -> --"},
+		},
+		{
+			name:     "comparison",
+			category: "comparison",
+			src: `func F(a, b int, s, t string) bool {
+	return a < b || a == b || s >= t
+}`,
+			want: []string{"< -> >=", "== -> !=", ">= -> <"},
+		},
+		{
+			name:     "negate-conditionals",
+			category: "negate-conditionals",
+			src: `func F(a int) int {
+	if a > 0 {
+		return 1
+	}
+	for !(a < 0) {
+		a--
+	}
+	switch {
+	case a == 1, a == 2:
+		return 2
+	}
+	switch a {
+	case 3:
+		return 3
+	}
+	return 0
+}`,
+			want: []string{"a > 0 -> !(a > 0)", "!(a < 0) -> (a < 0)", "a == 1 -> !(a == 1)", "a == 2 -> !(a == 2)"},
+		},
+		{
+			name:     "statement",
+			category: "statement",
+			src: `import "sort"
+
+func F(xs []int) (n int) {
+	for _, x := range xs {
+		if x < 0 {
+			continue
+		}
+		n += x
+		g()
+	}
+	defer g()
+	sort.Ints(xs)
+	return n
+}
+
+func g() {}`,
+			want: []string{"continue -> (removed)", "n += x -> (removed)", "g() -> (removed)", "defer g() -> (removed)"},
+		},
+		{
+			name:     "statement holding the last use of a variable",
+			category: "statement",
+			src: `func F() {
+	v := 1
+	v = 2
+	g(v)
+}
+
+func g(int) {}`,
+			want: []string{"v = 2 -> (removed)"},
+		},
+		{
+			name:     "statement ending a function with results",
+			category: "statement",
+			src: `func F(a int) int {
+	if a > 0 {
+		return a
+	} else {
+		panic("negative")
+	}
+}`,
+		},
+		{
+			name:     "return",
+			category: "return",
+			src: `type T struct{}
+
+func F(a, b int) (int, int, bool) {
+	return a, b, a > b
+}
+
+func G() (*T, []int) {
+	return &T{}, nil
+}
+
+func H(a int) int {
+	x := a * 2
+	return x
+}`,
+			want: []string{
+				"return a, b, a > b -> return 0, b, a > b",
+				"return a, b, a > b -> return b, a, a > b",
+				"return a, b, a > b -> return a, 0, a > b",
+				"return a, b, a > b -> return a, b, !(a > b)",
+				"return &T{}, nil -> return nil, nil",
+			},
+		},
+		{
+			name:     "return of a type parameter",
+			category: "return",
+			src: `func F[T any, P *int](f func() T, g func() P) (T, P) {
+	return f(), g()
+}`,
+		},
+		{
+			name:     "literal",
+			category: "literal",
+			src: `func F() (int, bool) {
+	x := 2
+	return x / 2, true
+}`,
+			want: []string{"2 -> 3", "2 -> 1", "2 -> 0", "2 -> 3", "2 -> 1", "true -> false"},
+		},
+		{
+			name:     "shadowed true",
+			category: "literal",
+			src: `func F() bool {
+	true := false
+	return true
+}`,
+			want: []string{"false -> true"},
+		},
+		{
+			name:     "unary",
+			category: "unary",
+			src: `func F(a int, b bool, s string) (int, bool, string) {
+	return -a + g(a), b, s
+}
+
+func g(a int) int { return 1 }`,
+			want: []string{"-a -> a", "g(a) -> -g(a)", "a -> -a", "b -> !b"},
+		},
+		{
+			name:     "index",
+			category: "index",
+			src: `func F(xs []int, a [2]int, m map[int]int, i int) int {
+	return xs[i] + a[0] + m[i] + len(xs[1:])
+}`,
+			want: []string{
+				"xs[i] -> xs[i - 1]",
+				"xs[i] -> xs[i + 1]",
+				"a[0] -> a[0 + 1]",
+				"xs[1:] -> xs[1 + 1:]",
+				"xs[1:] -> xs[1:len(xs) - 1]",
+			},
+		},
+		{
+			name:     "error",
+			category: "error",
+			src: `import "errors"
+
+var errX = errors.New("x")
+
+func F(f func() (int, error)) (int, error) {
+	n, err := f()
+	if err != nil {
+		return 0, err
+	}
+	var target *E
+	if errors.As(err, &target) {
+		return 0, nil
+	}
+	_, err = f()
+	return n, err
+}
+
+func G(f func() error) (bool, error) {
+	if err := f(); errX != nil {
+		return false, err
+	}
+	return errors.Is(f(), errX), nil
+}
+
+type E struct{}
+
+func (*E) Error() string { return "" }`,
+			want: []string{
+				"return 0, err -> return 0, nil",
+				"errors.As(err, &target) -> errors.As(nil, &target)",
+				"_, err = f() -> _, _ = f()",
+				"errors.Is(f(), errX) -> errors.Is(errX, f())",
+			},
+		},
+		{
+			name:     "shadowed nil",
+			category: "error",
+			src: `func F(f func() error) error {
+	var nil error
+	if err := f(); err != nil {
+		return err
+	}
+	return nil
+}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := "package p\n\n" + test.src + "\n"
+			fset, file, info := checkSource(t, src)
+			var got []string
+			for _, m := range FindMutants(file, info, map[string]bool{test.category: true}) {
+				got = append(got, m.Original+" -> "+m.Replacement)
+
+				// Every mutant must compile
+				m.Apply()
+				var buf bytes.Buffer
+				err := format.Node(&buf, fset, file)
+				m.Revert()
+				if err != nil {
+					t.Fatal(err)
+				}
+				mfset := token.NewFileSet()
+				mutated, err := parser.ParseFile(mfset, "p.go", buf.Bytes(), 0)
+				if err == nil {
+					_, err = typeCheckFile(mfset, mutated)
+				}
+				if err != nil {
+					t.Errorf("mutant %s -> %s does not compile: %s", m.Original, m.Replacement, err)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got mutants %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package mutator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Outcome is the result of running the tests against a mutation
+type Outcome int
+
+const (
+	// Killed means the tests failed, detecting the mutation
+	Killed Outcome = iota
+
+	// Survived means the tests passed despite the mutation
+	Survived
+
+	// BuildError means the mutated package or its tests did not compile
+	BuildError
+
+	// TestError means the tests could not be run to completion
+	TestError
+
+	// Ignored means the mutation was disabled by a comment directive and not tested
+	Ignored
+
+	// Equivalent means the mutated package compiled to the same test binary as the
+	// original, so the mutation can't be detected and wasn't tested
+	Equivalent
+
+	// Suppressed means the mutation survived but was accepted in the baseline, so
+	// it doesn't count against the score
+	Suppressed
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Killed:
+		return "killed"
+	case Survived:
+		return "survived"
+	case BuildError:
+		return "build error"
+	case TestError:
+		return "test error"
+	case Ignored:
+		return "ignored"
+	case Equivalent:
+		return "equivalent"
+	case Suppressed:
+		return "suppressed"
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// ParseOutcome returns the outcome with the given name, as returned by its String method
+func ParseOutcome(s string) (Outcome, error) {
+	for o := Killed; o <= Suppressed; o++ {
+		if o.String() == s {
+			return o, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown outcome %q", s)
+}
+
+// MarshalText encodes the outcome as its name
+func (o Outcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText decodes an outcome from its name
+func (o *Outcome) UnmarshalText(text []byte) error {
+	var err error
+	*o, err = ParseOutcome(string(text))
+	return err
+}
+
+// classifyFailure determines the outcome of a go test run that exited unsuccessfully
+// from its combined output.
+func classifyFailure(output []byte) Outcome {
+	lines := bytes.Split(bytes.TrimSpace(output), []byte("\n"))
+	lastLine := lines[len(lines)-1]
+	switch {
+	case !bytes.HasPrefix(lastLine, []byte("FAIL")):
+		return TestError
+	case bytes.HasSuffix(lastLine, []byte("[build failed]")), bytes.HasSuffix(lastLine, []byte("[setup failed]")):
+		return BuildError
+	}
+	return Killed
+}
+
+// Summary counts the outcomes of a set of mutations
+type Summary map[Outcome]int
+
+// Add adds the counts from other to s
+func (s Summary) Add(other Summary) {
+	for o, n := range other {
+		s[o] += n
+	}
+}
+
+// Total returns the total number of mutations in s
+func (s Summary) Total() int {
+	var total int
+	for _, n := range s {
+		total += n
+	}
+	return total
+}
+
+// Score returns the fraction of mutations that were killed by the tests.
+// Mutants that did not build, whose tests could not run or which are equivalent
+// to the original are not counted, since they say nothing about the quality of
+// the tests, and neither are suppressed mutants.
+func (s Summary) Score() float64 {
+	if s[Killed]+s[Survived] == 0 {
+		return 0
+	}
+	return float64(s[Killed]) / float64(s[Killed]+s[Survived])
+}
+
+// Print writes a human readable version of the summary to w
+func (s Summary) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d mutations: %d killed, %d survived, %d build errors, %d test errors, %d ignored, %d equivalent",
+		s.Total(), s[Killed], s[Survived], s[BuildError], s[TestError], s[Ignored], s[Equivalent])
+	if s[Suppressed] > 0 {
+		fmt.Fprintf(w, ", %d suppressed", s[Suppressed])
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "mutation score: %.1f%%\n", 100*s.Score())
+}
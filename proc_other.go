@@ -0,0 +1,9 @@
+//go:build !unix
+
+package mutator
+
+import "os/exec"
+
+// setProcessGroup does nothing on systems without process groups, where only
+// the go command is killed when its context is done
+func setProcessGroup(cmd *exec.Cmd) {}
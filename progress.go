@@ -0,0 +1,153 @@
+package mutator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress reports how far through testing the mutants of a package a run is,
+// with an estimate of the time remaining. On a terminal a single status line is
+// redrawn in place; otherwise a line is written at most every Interval.
+//
+// Progress is also an io.Writer: output written through it is kept separate
+// from the status line, so other messages can share the same terminal.
+type Progress struct {
+	W io.Writer
+
+	// TTY reports whether W is a terminal
+	TTY bool
+
+	// Interval is the minimum time between status lines when W isn't a terminal.
+	// If it's zero 10 seconds is used.
+	Interval time.Duration
+
+	mu       sync.Mutex
+	pkg      string
+	total    int
+	summary  Summary
+	start    time.Time
+	last     time.Time
+	shown    bool
+	stopTick chan struct{}
+}
+
+// NewProgress returns a Progress writing to f, which is checked to see whether it's a terminal
+func NewProgress(f *os.File) *Progress {
+	return &Progress{W: f, TTY: IsTerminal(f)}
+}
+
+// IsTerminal reports whether f is a terminal that supports redrawing lines
+func IsTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Start begins reporting progress through the total mutants of the named package
+func (p *Progress) Start(pkg string, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pkg, p.total = pkg, total
+	p.summary = make(Summary)
+	p.start = time.Now()
+	p.last = p.start
+	if p.TTY {
+		// Keep the elapsed time moving while a slow mutant is tested
+		p.stopTick = make(chan struct{})
+		go p.tick(p.stopTick)
+		p.draw()
+	}
+}
+
+func (p *Progress) tick(stop chan struct{}) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			p.mu.Lock()
+			p.draw()
+			p.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Report counts a tested mutant and updates the status
+func (p *Progress) Report(result Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.summary[result.Outcome]++
+	switch {
+	case p.TTY:
+		p.draw()
+	case time.Since(p.last) >= p.interval():
+		fmt.Fprintf(p.W, "%s\n", p.status())
+		p.last = time.Now()
+	}
+}
+
+// Finish stops reporting progress for the current package, clearing the status line
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopTick != nil {
+		close(p.stopTick)
+		p.stopTick = nil
+	}
+	p.clear()
+	p.total = 0
+}
+
+// Write writes b to the underlying writer above the status line
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	n, err := p.W.Write(b)
+	if p.TTY && p.total > 0 {
+		p.draw()
+	}
+	return n, err
+}
+
+func (p *Progress) interval() time.Duration {
+	if p.Interval > 0 {
+		return p.Interval
+	}
+	return 10 * time.Second
+}
+
+// status describes the progress through the current package
+func (p *Progress) status() string {
+	done := p.summary.Total()
+	elapsed := time.Since(p.start)
+	s := fmt.Sprintf("%s: %d/%d mutants, %s elapsed", p.pkg, done, p.total, elapsed.Round(time.Second))
+	if done > 0 {
+		// Using the wall clock time per mutant accounts for mutants tested in parallel
+		eta := elapsed / time.Duration(done) * time.Duration(p.total-done)
+		s += fmt.Sprintf(", ETA %s, %.1f%% killed", eta.Round(time.Second), 100*p.summary.Score())
+	}
+	return s
+}
+
+func (p *Progress) draw() {
+	if p.total == 0 {
+		return
+	}
+	fmt.Fprintf(p.W, "\r\033[K%s", p.status())
+	p.shown = true
+}
+
+func (p *Progress) clear() {
+	if p.shown {
+		fmt.Fprint(p.W, "\r\033[K")
+		p.shown = false
+	}
+}
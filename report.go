@@ -0,0 +1,123 @@
+package mutator
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"io"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of testing a single mutant
+type Result struct {
+	Mutant Mutant
+
+	// Package is the import path of the mutated package
+	Package string
+
+	// Position is the location of the mutant in the source
+	Position token.Position
+
+	// ID identifies the mutant independently of its position, in reports and
+	// history and baseline files
+	ID string
+
+	Outcome Outcome
+
+	// KilledBy is the mechanism that killed the mutant: KilledByTests, KilledByFuzzing
+	// or KilledByBenchmark. It's empty if the mutant wasn't killed, or if its outcome was
+	// cached before the mechanism was recorded.
+	KilledBy string
+
+	// Cached reports whether the outcome was taken from the cache instead of running the tests
+	Cached bool
+
+	// Output is the combined output of go test, or nil if the outcome was cached
+	Output []byte
+
+	// Duration is how long the tests took to run
+	Duration time.Duration
+
+	// Diff is a unified diff of the change made by the mutant
+	Diff string
+}
+
+// Name names the mutant of result in messages by its position and ID
+func (r Result) Name() string {
+	return fmt.Sprintf("%s (%s)", MutationID(r.Position), r.ID)
+}
+
+// Reporter receives the results of mutants as they are tested
+type Reporter interface {
+	Report(result Result)
+}
+
+// TextReporter writes a line describing each result to W
+type TextReporter struct {
+	W io.Writer
+
+	// Verbose adds the diff of each mutant and the output of its tests
+	Verbose bool
+}
+
+func (r *TextReporter) Report(result Result) {
+	var b bytes.Buffer
+	r.describe(&b, result)
+	if r.Verbose {
+		b.WriteString(indent([]byte(result.Diff)))
+		b.WriteString(indent(result.Output))
+	}
+	// Results are written at once, so that they aren't interleaved with other output
+	r.W.Write(b.Bytes())
+}
+
+// describe writes a line describing result to w
+func (r *TextReporter) describe(w io.Writer, result Result) {
+	if result.Cached {
+		fmt.Fprintf(w, "mutation %s %s (cached)\n", result.Name(), result.Outcome)
+		return
+	}
+
+	switch result.Outcome {
+	case Survived:
+		fmt.Fprintf(w, "mutation %s did not fail tests\n", result.Name())
+	case Killed:
+		switch result.KilledBy {
+		case KilledByFuzzing:
+			fmt.Fprintf(w, "mutation %s was killed by fuzzing\n", result.Name())
+		case KilledByBenchmark:
+			fmt.Fprintf(w, "mutation %s was killed by a benchmark\n", result.Name())
+		default:
+			fmt.Fprintf(w, "mutation %s tests failed as expected\n", result.Name())
+		}
+	case BuildError:
+		fmt.Fprintf(w, "mutation %s resulted in a build error\n", result.Name())
+	case Equivalent:
+		fmt.Fprintf(w, "mutation %s compiled to the same test binary as the original\n", result.Name())
+	case Suppressed:
+		fmt.Fprintf(w, "mutation %s did not fail tests but is accepted in the baseline\n", result.Name())
+	case TestError:
+		lines := bytes.Split(bytes.TrimSpace(result.Output), []byte("\n"))
+		fmt.Fprintf(w, "mutation %s tests resulted in an error: %s\n", result.Name(), lines[len(lines)-1])
+	}
+}
+
+// indent indents each line of text, which is returned with a trailing newline
+// unless it's empty
+func indent(text []byte) string {
+	text = bytes.TrimRight(text, "\n")
+	if len(text) == 0 {
+		return ""
+	}
+	return "\t" + strings.ReplaceAll(string(text), "\n", "\n\t") + "\n"
+}
+
+// MultiReporter passes each result to all of its reporters
+type MultiReporter []Reporter
+
+func (m MultiReporter) Report(result Result) {
+	for _, r := range m {
+		r.Report(result)
+	}
+}
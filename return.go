@@ -0,0 +1,160 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// ReturnVisitor finds returned values that can be replaced: booleans are negated,
+// numbers become zero, constructed values become nil and results of the same
+// type are swapped. The function's result types are used to keep the mutants
+// compilable, so nothing is mutated without type information.
+type ReturnVisitor struct {
+	// Info holds the type information of the file
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+
+	// results are the result types of the enclosing function
+	results *types.Tuple
+
+	// uses, if non-nil, counts the uses of the objects in the file that can't be left unused
+	uses map[types.Object]int
+}
+
+func (v *ReturnVisitor) Visit(node ast.Node) ast.Visitor {
+	if v.Info == nil {
+		return nil
+	}
+
+	var sig *types.Signature
+	var body *ast.BlockStmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		if obj := v.Info.Defs[n.Name]; obj != nil {
+			sig, _ = obj.Type().(*types.Signature)
+		}
+		body = n.Body
+	case *ast.FuncLit:
+		sig, _ = v.Info.Types[n].Type.(*types.Signature)
+		body = n.Body
+	case *ast.ReturnStmt:
+		v.mutate(n)
+		return v
+	default:
+		return v
+	}
+
+	// Each function is walked with its own result types
+	if sig != nil && body != nil {
+		inner := &ReturnVisitor{Info: v.Info, results: sig.Results(), uses: v.uses}
+		ast.Walk(inner, body)
+		v.Mutants = append(v.Mutants, inner.Mutants...)
+	}
+	return nil
+}
+
+// mutate records the mutations of the values returned by ret
+func (v *ReturnVisitor) mutate(ret *ast.ReturnStmt) {
+	// Bare returns and returns of a call with several results have nothing to replace
+	if v.results == nil || len(ret.Results) != v.results.Len() || len(ret.Results) == 0 {
+		return
+	}
+
+	for i, res := range ret.Results {
+		if usesLast(v.Info, v.uses, res) {
+			continue
+		}
+		i, res := i, res
+		typ := v.results.At(i).Type()
+		switch {
+		case isBasic(typ, types.IsBoolean):
+			// Returned true and false are already flipped by the literal category
+			if id, ok := res.(*ast.Ident); ok && (id.Name == "true" || id.Name == "false") && isUniverse(v.Info, id) {
+				break
+			}
+			v.replace(ret, i, negate(res))
+		case isBasic(typ, types.IsNumeric):
+			if tv := v.Info.Types[res]; tv.Value != nil && constant.Sign(tv.Value) == 0 {
+				break
+			}
+			v.replace(ret, i, &ast.BasicLit{ValuePos: res.Pos(), Kind: token.INT, Value: "0"})
+		case nillable(typ) && constructed(res):
+			v.replace(ret, i, &ast.Ident{NamePos: res.Pos(), Name: "nil"})
+		}
+	}
+
+	for i := range ret.Results {
+		for j := i + 1; j < len(ret.Results); j++ {
+			if !types.Identical(v.results.At(i).Type(), v.results.At(j).Type()) ||
+				types.ExprString(ret.Results[i]) == types.ExprString(ret.Results[j]) {
+				continue
+			}
+			i, j := i, j
+			swap := func() { ret.Results[i], ret.Results[j] = ret.Results[j], ret.Results[i] }
+			v.Mutants = append(v.Mutants, nodeMutant("return", ret, ret.Results[i].Pos(), swap, swap))
+		}
+	}
+}
+
+// replace records a mutation that replaces the i'th result of ret with repl
+func (v *ReturnVisitor) replace(ret *ast.ReturnStmt, i int, repl ast.Expr) {
+	orig := ret.Results[i]
+	v.Mutants = append(v.Mutants, nodeMutant("return", ret, orig.Pos(),
+		func() { ret.Results[i] = repl },
+		func() { ret.Results[i] = orig }))
+}
+
+// negate returns the negation of the boolean expression x
+func negate(x ast.Expr) ast.Expr {
+	if exp, ok := x.(*ast.UnaryExpr); ok && exp.Op == token.NOT {
+		return exp.X
+	}
+	if _, ok := x.(*ast.BinaryExpr); ok {
+		x = &ast.ParenExpr{X: x}
+	}
+	return &ast.UnaryExpr{OpPos: x.Pos(), Op: token.NOT, X: x}
+}
+
+// isBasic reports whether the underlying type of typ is a basic type with the given properties
+func isBasic(typ types.Type, info types.BasicInfo) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&info != 0
+}
+
+// nillable reports whether nil is a value of typ. It never is of a type
+// parameter, whatever its constraint.
+func nillable(typ types.Type) bool {
+	if _, ok := typ.(*types.TypeParam); ok {
+		return false
+	}
+	switch typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// constructed reports whether x builds a new value, rather than referring to an existing one
+func constructed(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.CompositeLit, *ast.CallExpr, *ast.FuncLit:
+		return true
+	case *ast.UnaryExpr:
+		_, ok := x.X.(*ast.CompositeLit)
+		return x.Op == token.AND && ok
+	}
+	return false
+}
+
+func returnMutants(file *ast.File, info *types.Info) []Mutant {
+	v := ReturnVisitor{Info: info}
+	if info != nil {
+		v.uses = countUses(info, file)
+	}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -0,0 +1,661 @@
+package mutator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options controls how packages are mutated and tested
+type Options struct {
+	// Build holds the build tags and target platform used to load and test packages
+	Build BuildConfig
+
+	// Categories is the set of enabled mutation categories
+	Categories map[string]bool
+
+	// TestFlags are additional flags passed to go test
+	TestFlags []string
+
+	// TestRun, if non-empty, is a regular expression selecting the tests to run as
+	// with go test -run. Tests are chosen for each mutant from among these.
+	TestRun string
+
+	// Filter selects the files and functions to mutate
+	Filter Filter
+
+	// Changed, if non-nil, restricts mutants to the lines it contains
+	Changed ChangedLines
+
+	// Only, if non-empty, restricts mutants to those with the given ID, or at the
+	// position with the given MutationID
+	Only string
+
+	// SelectTests runs only the tests covering each mutant rather than the whole suite
+	SelectTests bool
+
+	// Combiner, if non-nil, combines mutants into higher-order mutants before they're sampled
+	Combiner *Combiner
+
+	// Sampler, if non-nil, selects a random subset of mutants to test
+	Sampler *Sampler
+
+	// Cache, if non-nil, is used to skip mutants that have already been tested
+	Cache *Cache
+
+	// Baseline, if non-nil, holds accepted mutants which are reported as suppressed
+	// rather than survived
+	Baseline *Baseline
+
+	// Parallel is the number of mutants to test at once
+	Parallel int
+
+	// Timeout, if positive, limits how long the tests may run for each mutant
+	Timeout time.Duration
+
+	// Fuzz, if non-empty, is a pattern selecting a fuzz test which is run for
+	// FuzzTime against each mutant that survives its tests, as with go test -fuzz
+	Fuzz     string
+	FuzzTime time.Duration
+
+	// Bench, if non-empty, is a pattern selecting benchmarks which are run against
+	// each mutant that survives its tests and the fuzz test. Mutants are killed if a
+	// benchmark fails or is more than BenchThreshold percent slower than for the
+	// original package, or if the benchmarks run for longer than Timeout.
+	Bench          string
+	BenchThreshold float64
+
+	// Equivalent builds the test binary of each mutant before testing it. Mutants
+	// whose binary is the same as the original's are reported as equivalent.
+	Equivalent bool
+
+	// Docker, if non-nil, runs the tests of each mutant in a container rather than on the host
+	Docker *Docker
+
+	// Server, if non-nil, hands out each mutant to a remote worker to test
+	// rather than testing it locally
+	Server *Server
+
+	// KeepTmp prevents the temporary directory holding mutated sources from being removed
+	KeepTmp bool
+}
+
+// Runner tests the mutants of packages
+type Runner struct {
+	Options
+
+	// Reporter, if non-nil, receives the result of each tested mutant
+	Reporter Reporter
+
+	// Log, if non-nil, receives warnings and diagnostic messages
+	Log *Logger
+
+	// Progress, if non-nil, reports how far through each package the run is
+	Progress *Progress
+}
+
+func (r *Runner) logf(level Level, format string, args ...interface{}) {
+	r.Log.Printf(level, format, args...)
+}
+
+// tmpDirLevel is the level at which the temporary directory is logged, which
+// must be visible when it's kept
+func (r *Runner) tmpDirLevel() Level {
+	if r.KeepTmp {
+		return LevelNormal
+	}
+	return LevelVerbose
+}
+
+// ExpandPackages resolves package patterns such as ./... to a list of import paths
+func ExpandPackages(b BuildConfig, patterns []string) ([]string, error) {
+	args := append([]string{"list"}, patterns...)
+	cmd := b.Command(context.Background(), args...)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list packages: %s", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// Package is a parsed and type checked package
+type Package struct {
+	*build.Package
+
+	// Build holds the build settings the package was loaded with
+	Build BuildConfig
+
+	Fset  *token.FileSet
+	Files []*ast.File
+	Info  *types.Info
+}
+
+// LoadPackage parses and type checks the non-test files of the named package.
+// Only these files are mutated: the package's internal and external test files
+// are left to go test, so the tests build exactly as they would without mutator.
+// Files are selected according to the build settings of b.
+func LoadPackage(b BuildConfig, name string) (*Package, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	bpkg, err := b.Context().Import(name, wd, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not import %s: %s", name, err)
+	}
+
+	pkg := &Package{Package: bpkg, Build: b, Fset: token.NewFileSet()}
+	for _, f := range pkg.GoFiles {
+		srcFile := filepath.Join(pkg.Dir, f)
+		file, err := parser.ParseFile(pkg.Fset, srcFile, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s: %s", srcFile, err)
+		}
+		pkg.Files = append(pkg.Files, file)
+	}
+	pkg.Info = typeCheck(b, pkg.Dir, pkg.ImportPath, pkg.Fset, pkg.Files)
+	return pkg, nil
+}
+
+// Source returns the source of file, which must belong to pkg, with the mutant
+// m applied. If m is nil the unmutated source is returned.
+func (pkg *Package) Source(file *ast.File, m *Mutant) ([]byte, error) {
+	if m != nil {
+		m.Apply()
+		defer m.Revert()
+	}
+	return formatAST(pkg.Fset, file)
+}
+
+// Mutants returns the mutants of file selected by the runner's options, separating
+// out those disabled by comment directives
+func (r *Runner) Mutants(pkg *Package, file *ast.File) (mutants, ignored []Mutant) {
+	mutants = FindMutants(file, pkg.Info, r.Categories)
+	setIDs(pkg.Fset, file, mutants)
+	mutants = r.Filter.apply(pkg.Fset, file, mutants)
+	if r.Only != "" {
+		var only []Mutant
+		for _, m := range mutants {
+			if matchID(r.Only, m, pkg.Fset.Position(m.Pos)) {
+				only = append(only, m)
+			}
+		}
+		mutants = only
+	}
+	if r.Changed != nil {
+		var changed []Mutant
+		for _, m := range mutants {
+			if r.Changed.Contains(pkg.Fset.Position(m.Pos)) {
+				changed = append(changed, m)
+			}
+		}
+		mutants = changed
+	}
+	return FilterDisabled(pkg.Fset, file, mutants)
+}
+
+// Run tests the mutants of every file in the named package. If ctx is cancelled
+// the summary of the mutants tested so far is returned along with ctx.Err().
+func (r *Runner) Run(ctx context.Context, name string) (Summary, error) {
+	pkg, err := LoadPackage(r.Build, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.runPackage(ctx, pkg)
+}
+
+// runPackage tests the mutants of every file in the loaded package pkg, as Run does
+func (r *Runner) runPackage(ctx context.Context, pkg *Package) (Summary, error) {
+	if r.Build.GOCACHE == "" {
+		var err error
+		if r.Build.GOCACHE, err = goCache(); err != nil {
+			return nil, err
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", "mutate")
+	if err != nil {
+		return nil, fmt.Errorf("could not create temporary directory: %s", err)
+	}
+
+	r.logf(r.tmpDirLevel(), "using %s as a temporary directory\n", tmpDir)
+	if !r.KeepTmp {
+		defer os.RemoveAll(tmpDir)
+	}
+
+	summary := make(Summary)
+	mutants := make([][]Mutant, len(pkg.Files))
+	for i, file := range pkg.Files {
+		var ignored []Mutant
+		mutants[i], ignored = r.Mutants(pkg, file)
+		summary[Ignored] += len(ignored)
+	}
+	if r.Combiner != nil {
+		mutants = r.Combiner.Combine(mutants)
+	}
+	if r.Sampler != nil {
+		mutants = r.Sampler.Sample(mutants)
+	}
+	var total int
+	for i, file := range pkg.Files {
+		r.logf(LevelVerbose, "%s has %d mutation sites\n", filepath.Base(pkg.Fset.File(file.Pos()).Name()), len(mutants[i]))
+		total += len(mutants[i])
+	}
+
+	if r.Server == nil && total > 0 && hasTests(pkg) {
+		r.logf(LevelVerbose, "building dependencies\n")
+		if err := warmCache(ctx, r.Build, r.Docker, pkg.Dir, r.TestFlags); ctx.Err() != nil {
+			return summary, ctx.Err()
+		} else if err != nil {
+			r.logf(LevelNormal, "%s\n", err)
+		}
+	}
+
+	var cov TestCoverage
+	if r.SelectTests && !hasRunFlag(r.TestFlags) && total > 0 && hasTests(pkg) {
+		cov, err = BuildTestCoverage(ctx, pkg, tmpDir, r.TestRun, r.TestFlags)
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		} else if err != nil {
+			r.logf(LevelNormal, "could not map tests to lines, running all tests: %s\n", err)
+		}
+	}
+
+	var base map[string]string
+	if r.Equivalent && total > 0 && hasTests(pkg) {
+		base, err = r.originalHashes(ctx, pkg, mutants, tmpDir)
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		} else if err != nil {
+			r.logf(LevelNormal, "could not build the original test binary, equivalent mutants won't be detected: %s\n", err)
+		}
+	}
+
+	var fuzz string
+	if r.Fuzz != "" && total > 0 && hasTests(pkg) {
+		fuzz, err = r.fuzzTarget(ctx, pkg)
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		} else if err != nil {
+			r.logf(LevelNormal, "mutants won't be killed by fuzzing: %s\n", err)
+		}
+	}
+
+	var bench map[string]float64
+	if r.Bench != "" && total > 0 && hasTests(pkg) {
+		r.logf(LevelVerbose, "running benchmarks of the original package\n")
+		bench, err = r.benchmarkOriginal(ctx, pkg)
+		if ctx.Err() != nil {
+			return summary, ctx.Err()
+		} else if err != nil {
+			r.logf(LevelNormal, "mutants won't be killed by benchmarks: %s\n", err)
+		}
+	}
+
+	if r.Progress != nil {
+		r.Progress.Start(pkg.ImportPath, total)
+		defer r.Progress.Finish()
+	}
+
+	err = r.test(ctx, pkg, mutants, cov, base, fuzz, bench, tmpDir, summary)
+	return summary, err
+}
+
+// Test runs the tests of pkg against the mutant m of file, ignoring the cache and
+// test selection, and returns its result including the output of go test
+func (r *Runner) Test(ctx context.Context, pkg *Package, file *ast.File, m Mutant) (Result, error) {
+	tmpDir, err := ioutil.TempDir("", "mutate")
+	if err != nil {
+		return Result{}, fmt.Errorf("could not create temporary directory: %s", err)
+	}
+	r.logf(r.tmpDirLevel(), "using %s as a temporary directory\n", tmpDir)
+	if !r.KeepTmp {
+		defer os.RemoveAll(tmpDir)
+	}
+
+	orig, err := pkg.Source(file, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	j, err := newJob(pkg, file, orig, m)
+	if err != nil {
+		return Result{}, err
+	}
+	err = r.runTests(ctx, &j, nil, "", "", nil, tmpDir)
+	return j.result, err
+}
+
+// hasTests reports whether pkg has any internal or external test files
+func hasTests(pkg *Package) bool {
+	return len(pkg.TestGoFiles)+len(pkg.XTestGoFiles) > 0
+}
+
+// job is a mutant waiting to be tested, or that has been tested
+type job struct {
+	result Result
+	// positions holds the position of every change made by the mutant
+	positions []token.Position
+	srcFile   string
+	src       []byte
+	key       string
+	err       error
+}
+
+// test runs the tests of pkg against each of its mutants, which are grouped by file,
+// adding their outcomes to summary. Up to r.Parallel mutants are tested at once.
+// base holds the hashes of the original test binaries by file if they were built,
+// fuzz names the fuzz test to run if there is one, and bench holds the results of
+// the original's benchmarks if they were run.
+func (r *Runner) test(ctx context.Context, pkg *Package, mutants [][]Mutant, cov TestCoverage, base map[string]string, fuzz string, bench map[string]float64, tmpDir string, summary Summary) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan job)
+	done := make(chan job)
+	var wg sync.WaitGroup
+
+	// The syntax trees are only modified by this goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		for i, file := range pkg.Files {
+			if len(mutants[i]) == 0 {
+				continue
+			}
+			orig, err := pkg.Source(file, nil)
+			if err != nil {
+				done <- job{err: err}
+				return
+			}
+			for _, m := range mutants[i] {
+				j, err := newJob(pkg, file, orig, m)
+				if err == nil && r.Cache != nil {
+					err = r.lookup(pkg, &j)
+				}
+				if err != nil || j.result.Cached {
+					j.err = err
+					done <- j
+					continue
+				}
+				select {
+				case jobs <- j:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	workers := r.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("worker%d", i))
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return fmt.Errorf("could not create worker directory: %s", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				j.err = r.runTests(ctx, &j, cov, base[j.srcFile], fuzz, bench, dir)
+				done <- j
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var firstErr error
+	for j := range done {
+		if firstErr != nil {
+			continue
+		}
+		if j.err == nil && !j.result.Cached && r.Cache != nil && j.result.Outcome != TestError {
+			// Test errors are often transient, so they aren't worth remembering
+			j.err = r.Cache.Put(j.key, j.result.Outcome, j.result.KilledBy)
+		}
+		if j.err != nil {
+			firstErr = j.err
+			cancel()
+			continue
+		}
+		// The cache holds the tested outcome, so that changes to the baseline apply to it
+		if r.Baseline != nil && j.result.Outcome == Survived && r.Baseline.Contains(j.result) {
+			j.result.Outcome = Suppressed
+		}
+		r.report(summary, j.result)
+	}
+	return firstErr
+}
+
+// newJob applies m to file to produce the mutated source, which is compared with
+// the unmutated source orig.
+func newJob(pkg *Package, file *ast.File, orig []byte, m Mutant) (job, error) {
+	src, err := pkg.Source(file, &m)
+	if err != nil {
+		return job{}, err
+	}
+
+	pos := pkg.Fset.Position(m.Pos)
+	srcFile := pkg.Fset.File(file.Pos()).Name()
+	id := m.ID
+	if id == "" {
+		id = MutationID(pos)
+	}
+	positions := []token.Position{pos}
+	if len(m.positions) > 0 {
+		positions = positions[:0]
+		for _, p := range m.positions {
+			positions = append(positions, pkg.Fset.Position(p))
+		}
+	}
+	return job{
+		result: Result{
+			Mutant:   m,
+			Package:  pkg.ImportPath,
+			Position: pos,
+			ID:       id,
+			Diff:     Diff(filepath.Base(srcFile), orig, src),
+		},
+		positions: positions,
+		srcFile:   srcFile,
+		src:       src,
+	}, nil
+}
+
+// lookup sets the cache key of j, and completes its result if the outcome is in the cache
+func (r *Runner) lookup(pkg *Package, j *job) error {
+	var err error
+	flags := r.TestFlags
+	if r.TestRun != "" {
+		flags = append([]string{"-run=" + r.TestRun}, flags...)
+	}
+	if r.Timeout > 0 {
+		flags = append([]string{"-timeout=" + r.Timeout.String()}, flags...)
+	}
+	if r.Equivalent {
+		flags = append([]string{"-equivalent"}, flags...)
+	}
+	if r.Docker != nil {
+		flags = append([]string{"-docker=" + r.Docker.Image}, flags...)
+	}
+	if r.Fuzz != "" {
+		flags = append([]string{"-fuzz=" + r.Fuzz, "-fuzztime=" + r.FuzzTime.String()}, flags...)
+	}
+	if r.Bench != "" {
+		flags = append([]string{"-bench=" + r.Bench, fmt.Sprintf("-bench-threshold=%g", r.BenchThreshold)}, flags...)
+	}
+	if j.key, err = r.Cache.Key(pkg, flags, j.srcFile, j.src); err != nil {
+		return err
+	}
+	if outcome, killedBy, ok := r.Cache.Get(j.key); ok {
+		j.result.Outcome, j.result.KilledBy, j.result.Cached = outcome, killedBy, true
+	}
+	return nil
+}
+
+// runTests tests the mutated source of j, setting its outcome. The original file is
+// left untouched: the mutated source is written to dir and substituted using
+// go test -overlay. If cov is non-nil only the tests covering the mutant are run.
+// If base is non-empty the mutant's test binary is built first, and the tests
+// aren't run if it has the hash base, as the mutant is equivalent to the original.
+// The binary is built on the host even if the tests are run in a container or
+// by a remote worker. If the mutant survives its tests, it's fuzzed with the fuzz
+// test fuzz and its benchmarks are compared with bench, if they're non-empty.
+func (r *Runner) runTests(ctx context.Context, j *job, cov TestCoverage, base, fuzz string, bench map[string]float64, dir string) error {
+	mutatedFile := filepath.Join(dir, filepath.Base(j.srcFile))
+	if err := ioutil.WriteFile(mutatedFile, j.src, 0644); err != nil {
+		return fmt.Errorf("could not write mutated file: %s", err)
+	}
+	overlay := filepath.Join(dir, "overlay.json")
+	if err := writeOverlay(overlay, map[string]string{j.srcFile: mutatedFile}); err != nil {
+		return fmt.Errorf("could not write overlay: %s", err)
+	}
+
+	start := time.Now()
+	if base != "" {
+		binary := filepath.Join(dir, "mutant.test")
+		output, err := r.buildTestBinary(ctx, filepath.Dir(j.srcFile), binary, overlay)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				return fmt.Errorf("mutation %s failed to build tests: %s\n", j.result.ID, err)
+			}
+			j.result.Outcome, j.result.Output = BuildError, output
+			j.result.Duration = time.Since(start)
+			return nil
+		}
+		hash, err := r.binaryHash(ctx, binary)
+		if err != nil {
+			return err
+		}
+		if hash == base {
+			j.result.Outcome, j.result.Output = Equivalent, output
+			j.result.Duration = time.Since(start)
+			return nil
+		}
+	}
+
+	flags := r.testFlags(j, cov)
+	if r.Server != nil {
+		return r.Server.test(ctx, j, r.Build, flags)
+	}
+	args := append([]string{"test", "-overlay=" + overlay}, flags...)
+	outcome, output, err := goTest(ctx, r.Build, r.Docker, filepath.Dir(j.srcFile), []string{dir}, args)
+	j.result.Duration = time.Since(start)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("mutation %s failed to run tests: %s\n", j.result.ID, err)
+	}
+	j.result.Outcome, j.result.Output = outcome, output
+	if outcome == Killed {
+		j.result.KilledBy = KilledByTests
+	}
+
+	if outcome == Survived && (fuzz != "" || bench != nil) {
+		outcome, output, killedBy, err := r.killedBy(ctx, j, overlay, fuzz, bench, dir)
+		j.result.Duration = time.Since(start)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("mutation %s failed to run fuzz test or benchmarks: %s\n", j.result.ID, err)
+		}
+		if outcome != Survived {
+			j.result.Outcome, j.result.KilledBy = outcome, killedBy
+			j.result.Output = append(j.result.Output, output...)
+		}
+	}
+	return nil
+}
+
+// testFlags returns the flags passed to go test for j, apart from -overlay
+func (r *Runner) testFlags(j *job, cov TestCoverage) []string {
+	var flags []string
+	if r.Timeout > 0 {
+		flags = append(flags, "-timeout", r.Timeout.String())
+	}
+	pattern := ""
+	if cov != nil {
+		pattern = cov.RunPattern(j.positions...)
+	}
+	if pattern != "" {
+		flags = append(flags, "-run", pattern)
+	} else if r.TestRun != "" {
+		flags = append(flags, "-run", r.TestRun)
+	}
+	return append(flags, r.TestFlags...)
+}
+
+// goTest runs the go tool with args in pkgDir and returns the outcome and output
+// of the tests. If d is non-nil they're run in a container, with the directories
+// in mounts mounted read-only.
+func goTest(ctx context.Context, b BuildConfig, d *Docker, pkgDir string, mounts []string, args []string) (Outcome, []byte, error) {
+	cmd := b.Command(ctx, args...)
+	setProcessGroup(cmd)
+	if d != nil {
+		var remove func()
+		var err error
+		if cmd, remove, err = d.Command(ctx, b, pkgDir, mounts, args...); err != nil {
+			return 0, nil, err
+		}
+		defer func() {
+			if ctx.Err() != nil {
+				remove()
+			}
+		}()
+	}
+	cmd.Dir = pkgDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return 0, nil, err
+		}
+		return classifyFailure(output), output, nil
+	}
+	return Survived, output, nil
+}
+
+// report counts the outcome of result in summary and passes it to the reporter
+// and progress
+func (r *Runner) report(summary Summary, result Result) {
+	summary[result.Outcome]++
+	if r.Reporter != nil {
+		r.Reporter.Report(result)
+	}
+	if r.Progress != nil {
+		r.Progress.Report(result)
+	}
+}
+
+func formatAST(fset *token.FileSet, file *ast.File) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("could not print %s: %s", fset.File(file.Pos()).Name(), err)
+	}
+	return buf.Bytes(), nil
+}
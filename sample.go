@@ -0,0 +1,68 @@
+package mutator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Sampler randomly selects a subset of the mutations of each package
+type Sampler struct {
+	// Rate is the fraction of mutations to select
+	Rate float64
+
+	// Max, if positive, is the maximum number of mutations to select per package
+	Max int
+
+	// Seed is the seed of the random number generator, which makes runs reproducible
+	Seed int64
+
+	rng *rand.Rand
+}
+
+func (s *Sampler) String() string {
+	str := fmt.Sprintf("sampled %g%% of mutations", 100*s.Rate)
+	if s.Max > 0 {
+		str += fmt.Sprintf(" up to %d per package", s.Max)
+	}
+	return str + fmt.Sprintf(" with -seed %d", s.Seed)
+}
+
+// Sample returns a random subset of the mutations of a package, which are grouped
+// by file. The order of the mutations within each file is preserved.
+func (s *Sampler) Sample(files [][]Mutant) [][]Mutant {
+	type index struct{ file, i int }
+	var all []index
+	for file, mutations := range files {
+		for i := range mutations {
+			all = append(all, index{file, i})
+		}
+	}
+
+	n := int(math.Ceil(s.Rate * float64(len(all))))
+	if s.Max > 0 && n > s.Max {
+		n = s.Max
+	}
+	if n >= len(all) {
+		return files
+	}
+
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewSource(s.Seed))
+	}
+	s.rng.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+	selected := all[:n]
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].file != selected[j].file {
+			return selected[i].file < selected[j].file
+		}
+		return selected[i].i < selected[j].i
+	})
+
+	sampled := make([][]Mutant, len(files))
+	for _, idx := range selected {
+		sampled[idx.file] = append(sampled[idx.file], files[idx.file][idx.i])
+	}
+	return sampled
+}
@@ -0,0 +1,78 @@
+package mutator
+
+import (
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// testFiles returns the given numbers of mutants in each of several files,
+// positioned by their index
+func testFiles(counts ...int) [][]Mutant {
+	files := make([][]Mutant, len(counts))
+	pos := token.Pos(1)
+	for i, n := range counts {
+		for j := 0; j < n; j++ {
+			files[i] = append(files[i], Mutant{Pos: pos})
+			pos++
+		}
+	}
+	return files
+}
+
+// positions returns the positions of the mutants in each file
+func positions(files [][]Mutant) [][]token.Pos {
+	var out [][]token.Pos
+	for _, mutants := range files {
+		var ps []token.Pos
+		for _, m := range mutants {
+			ps = append(ps, m.Pos)
+		}
+		out = append(out, ps)
+	}
+	return out
+}
+
+func TestSampleDeterministic(t *testing.T) {
+	sample := func(seed int64) [][]token.Pos {
+		s := &Sampler{Rate: 0.3, Seed: seed}
+		// The generator is shared by the packages of a run
+		return append(positions(s.Sample(testFiles(10, 20, 5))), positions(s.Sample(testFiles(8)))...)
+	}
+	first := sample(1)
+	if second := sample(1); !reflect.DeepEqual(first, second) {
+		t.Errorf("samples with the same seed differ: %v and %v", first, second)
+	}
+	if other := sample(2); reflect.DeepEqual(first, other) {
+		t.Errorf("samples with different seeds are both %v", first)
+	}
+}
+
+func TestSampleSize(t *testing.T) {
+	tests := []struct {
+		rate float64
+		max  int
+		want int
+	}{
+		{rate: 0.3, want: 11},
+		{rate: 0.3, max: 5, want: 5},
+		{rate: 1, max: 50, want: 35},
+		{rate: 0.01, want: 1},
+	}
+	for _, test := range tests {
+		s := &Sampler{Rate: test.rate, Max: test.max, Seed: 1}
+		sampled := s.Sample(testFiles(10, 20, 5))
+		n := 0
+		for _, mutants := range sampled {
+			for i := 1; i < len(mutants); i++ {
+				if mutants[i-1].Pos >= mutants[i].Pos {
+					t.Errorf("rate %g: mutants out of order: %v", test.rate, positions(sampled))
+				}
+			}
+			n += len(mutants)
+		}
+		if n != test.want {
+			t.Errorf("rate %g, max %d: got %d mutants, want %d", test.rate, test.max, n, test.want)
+		}
+	}
+}
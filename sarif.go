@@ -0,0 +1,136 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// SARIFReporter collects surviving mutants and writes them as a SARIF log, so
+// they can be shown by code scanning tools. Each category is a rule.
+type SARIFReporter struct {
+	// BaseDir is the directory file locations are made relative to. If it's empty
+	// absolute file URIs are used.
+	BaseDir string
+
+	results []Result
+}
+
+func (r *SARIFReporter) Report(result Result) {
+	if result.Outcome == Survived {
+		r.results = append(r.results, result)
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// Write writes the SARIF log of the surviving mutants reported so far to w
+func (r *SARIFReporter) Write(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "mutator",
+			InformationURI: "https://github.com/kisielk/mutator",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	categories := make(map[string]bool)
+	for _, result := range r.results {
+		categories[result.Mutant.Category] = true
+		run.Results = append(run.Results, sarifResult{
+			RuleID: result.Mutant.Category,
+			Level:  "warning",
+			Message: sarifMessage{Text: fmt.Sprintf("Surviving mutant: %s -> %s",
+				result.Mutant.Original, result.Mutant.Replacement)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: r.uri(result.Position.Filename)},
+				Region:           sarifRegion{StartLine: result.Position.Line, StartColumn: result.Position.Column},
+			}}},
+		})
+	}
+
+	var names []string
+	for category := range categories {
+		names = append(names, category)
+	}
+	sort.Strings(names)
+	for _, category := range names {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               category,
+			ShortDescription: sarifMessage{Text: fmt.Sprintf("A %s mutation was not detected by the tests", category)},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// uri returns the URI of filename, relative to BaseDir if possible
+func (r *SARIFReporter) uri(filename string) string {
+	if rel := relPath(r.BaseDir, filename); !filepath.IsAbs(filepath.FromSlash(rel)) {
+		return rel
+	}
+	return "file://" + filepath.ToSlash(filename)
+}
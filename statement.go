@@ -0,0 +1,153 @@
+package mutator
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+)
+
+// StatementVisitor finds statements that can be removed from block bodies
+type StatementVisitor struct {
+	// Info, if non-nil, is used to keep statements holding the last use of a
+	// variable or an import
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+
+	// uses, if non-nil, counts the uses of the objects in the file that can't be left unused
+	uses map[types.Object]int
+
+	// terminating holds the panics ending functions with results, which would
+	// be missing a return without them
+	terminating map[ast.Stmt]bool
+}
+
+func (v *StatementVisitor) Visit(node ast.Node) ast.Visitor {
+	var list []ast.Stmt
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		if n.Body != nil && n.Type.Results != nil {
+			v.markTerminating(n.Body)
+		}
+		return v
+	case *ast.FuncLit:
+		if n.Type.Results != nil {
+			v.markTerminating(n.Body)
+		}
+		return v
+	case *ast.BlockStmt:
+		list = n.List
+	case *ast.CaseClause:
+		list = n.Body
+	case *ast.CommClause:
+		list = n.Body
+	default:
+		return v
+	}
+
+	for i, stmt := range list {
+		if !removable(stmt) || v.terminating[stmt] || usesLast(v.Info, v.uses, stmt) {
+			continue
+		}
+		i, stmt := i, stmt
+		v.Mutants = append(v.Mutants, Mutant{
+			Pos:         stmt.Pos(),
+			Category:    "statement",
+			Original:    stmtString(stmt),
+			Replacement: "(removed)",
+			// An implicit empty statement keeps the surrounding list intact and prints as nothing.
+			Apply:  func() { list[i] = &ast.EmptyStmt{Semicolon: stmt.Pos(), Implicit: true} },
+			Revert: func() { list[i] = stmt },
+		})
+	}
+	return v
+}
+
+// removable reports whether stmt can be removed without introducing an obvious compile error
+func removable(stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		// Removing a short variable declaration leaves its uses undefined
+		return s.Tok != token.DEFINE
+	case *ast.IncDecStmt, *ast.DeferStmt:
+		return true
+	case *ast.ExprStmt:
+		_, ok := s.X.(*ast.CallExpr)
+		return ok
+	case *ast.BranchStmt:
+		return s.Tok == token.BREAK || s.Tok == token.CONTINUE
+	}
+	return false
+}
+
+// markTerminating records the panics that make stmt a terminating statement as
+// defined by the spec, such as those ending each branch of an if statement
+func (v *StatementVisitor) markTerminating(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "panic" && isUniverse(v.Info, id) {
+				if v.terminating == nil {
+					v.terminating = make(map[ast.Stmt]bool)
+				}
+				v.terminating[s] = true
+			}
+		}
+	case *ast.BlockStmt:
+		v.markLast(s.List)
+	case *ast.LabeledStmt:
+		v.markTerminating(s.Stmt)
+	case *ast.IfStmt:
+		if s.Else != nil {
+			v.markTerminating(s.Body)
+			v.markTerminating(s.Else)
+		}
+	case *ast.SwitchStmt:
+		v.markClauses(s.Body)
+	case *ast.TypeSwitchStmt:
+		v.markClauses(s.Body)
+	case *ast.SelectStmt:
+		v.markClauses(s.Body)
+	}
+}
+
+// markLast records the panics ending the statement list
+func (v *StatementVisitor) markLast(list []ast.Stmt) {
+	if len(list) > 0 {
+		v.markTerminating(list[len(list)-1])
+	}
+}
+
+// markClauses records the panics ending each clause of a switch or select statement
+func (v *StatementVisitor) markClauses(body *ast.BlockStmt) {
+	for _, clause := range body.List {
+		switch c := clause.(type) {
+		case *ast.CaseClause:
+			v.markLast(c.Body)
+		case *ast.CommClause:
+			v.markLast(c.Body)
+		}
+	}
+}
+
+// stmtString returns the first line of the source representation of stmt
+func stmtString(stmt ast.Stmt) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), stmt)
+	if i := bytes.IndexByte(buf.Bytes(), '\n'); i >= 0 {
+		return string(buf.Bytes()[:i]) + " ..."
+	}
+	return buf.String()
+}
+
+func statementMutants(file *ast.File, info *types.Info) []Mutant {
+	v := StatementVisitor{Info: info}
+	if info != nil {
+		v.uses = countUses(info, file)
+	}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -0,0 +1,207 @@
+package mutator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+)
+
+// typeCheck type checks the files of the package with the given import path in
+// dir. Dependencies are imported from their export data, as compiled by the go
+// tool with the build settings of b. Type errors are ignored so that partial
+// information is still returned for packages whose dependencies can't be loaded.
+func typeCheck(b BuildConfig, dir, path string, fset *token.FileSet, files []*ast.File) *types.Info {
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	imp := importer.ForCompiler(fset, "source", nil)
+	if exports, err := listExports(b, dir); err == nil {
+		imp = importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+			export, ok := exports[path]
+			if !ok || export == "" {
+				return nil, fmt.Errorf("no export data for %s", path)
+			}
+			return os.Open(export)
+		})
+	}
+	conf := types.Config{
+		Importer: imp,
+		Error:    func(error) {},
+	}
+	conf.Check(path, fset, files, info)
+	return info
+}
+
+// listExports returns the export data files of the dependencies of the package
+// in dir by the paths they're imported with, which differ from their import paths
+// for vendored packages
+func listExports(b BuildConfig, dir string) (map[string]string, error) {
+	cmd := b.Command(context.Background(), "list", "-export", "-deps", "-json=ImportPath,Export,ImportMap", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list dependencies: %s", err)
+	}
+
+	exports := make(map[string]string)
+	importMap := make(map[string]string)
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p struct {
+			ImportPath string
+			Export     string
+			ImportMap  map[string]string
+		}
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		exports[p.ImportPath] = p.Export
+		for from, to := range p.ImportMap {
+			importMap[from] = to
+		}
+	}
+	for from, to := range importMap {
+		exports[from] = exports[to]
+	}
+	return exports, nil
+}
+
+// validOperator reports whether the binary operator op can be applied to
+// the operand x. It returns true when the type of x is unknown.
+func validOperator(info *types.Info, x ast.Expr, op token.Token) bool {
+	if info == nil {
+		return true
+	}
+	tv, ok := info.Types[x]
+	if !ok || tv.Type == nil {
+		return true
+	}
+	basic, ok := tv.Type.Underlying().(*types.Basic)
+	if !ok {
+		return true
+	}
+
+	switch op {
+	case token.ADD:
+		return basic.Info()&(types.IsNumeric|types.IsString) != 0
+	case token.SUB, token.MUL, token.QUO:
+		return basic.Info()&types.IsNumeric != 0
+	case token.REM, token.AND, token.OR, token.XOR, token.AND_NOT, token.SHL, token.SHR:
+		return basic.Info()&types.IsInteger != 0
+	case token.LSS, token.GTR, token.LEQ, token.GEQ:
+		return basic.Info()&types.IsOrdered != 0
+	}
+	return true
+}
+
+// isUniverse reports whether id refers to a predeclared object rather than
+// a local declaration that shadows it. It returns true when id is unresolved.
+func isUniverse(info *types.Info, id *ast.Ident) bool {
+	if info == nil {
+		return true
+	}
+	if info.Defs[id] != nil {
+		return false
+	}
+	obj, ok := info.Uses[id]
+	return !ok || obj.Parent() == types.Universe
+}
+
+// inspectReads calls f for each identifier in node other than those assigned
+// to with = or :=, which don't count as uses of a variable
+func inspectReads(node ast.Node, f func(*ast.Ident)) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.ASSIGN && n.Tok != token.DEFINE {
+				return true
+			}
+			for _, lhs := range n.Lhs {
+				if _, ok := ast.Unparen(lhs).(*ast.Ident); !ok {
+					inspectReads(lhs, f)
+				}
+			}
+			for _, rhs := range n.Rhs {
+				inspectReads(rhs, f)
+			}
+			return false
+		case *ast.Ident:
+			f(n)
+		}
+		return true
+	})
+}
+
+// countUses counts the uses in file of the objects which are a compile error to
+// leave unused: imported packages and local variables other than parameters
+func countUses(info *types.Info, file *ast.File) map[types.Object]int {
+	params := make(map[types.Object]bool)
+	addParams := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				params[info.Defs[name]] = true
+			}
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			addParams(n.Recv)
+		case *ast.FuncType:
+			addParams(n.Params)
+			addParams(n.Results)
+		}
+		return true
+	})
+
+	uses := make(map[types.Object]int)
+	inspectReads(file, func(id *ast.Ident) {
+		switch obj := info.Uses[id].(type) {
+		case *types.PkgName:
+			uses[obj]++
+		case *types.Var:
+			if !obj.IsField() && obj.Pkg() != nil && obj.Parent() != obj.Pkg().Scope() && !params[obj] {
+				uses[obj]++
+			}
+		}
+	})
+	return uses
+}
+
+// usesLast reports whether x holds all the uses of one of the objects counted
+// in uses, which would then be unused if x were replaced or removed. It returns
+// false if uses is nil.
+func usesLast(info *types.Info, uses map[types.Object]int, x ast.Node) bool {
+	if uses == nil {
+		return false
+	}
+	inX := make(map[types.Object]int)
+	inspectReads(x, func(id *ast.Ident) {
+		if obj := info.Uses[id]; obj != nil {
+			if _, ok := uses[obj]; ok {
+				inX[obj]++
+			}
+		}
+	})
+	for obj, n := range inX {
+		if n >= uses[obj] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// checkSource parses and type checks src as the only file of a package,
+// failing the test if it has errors
+func checkSource(t *testing.T, src string) (*token.FileSet, *ast.File, *types.Info) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := typeCheckFile(fset, file)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, src)
+	}
+	return fset, file, info
+}
+
+// typeCheckFile type checks file as the only file of a package
+func typeCheckFile(fset *token.FileSet, file *ast.File) (*types.Info, error) {
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err := conf.Check("p", fset, []*ast.File{file}, info)
+	return info, err
+}
+
+// findIdent returns the nth identifier in file with the given name, counting from 0
+func findIdent(file *ast.File, name string, n int) *ast.Ident {
+	var found *ast.Ident
+	ast.Inspect(file, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Ident); ok && id.Name == name && found == nil {
+			if n == 0 {
+				found = id
+			}
+			n--
+		}
+		return found == nil
+	})
+	return found
+}
+
+func TestValidOperator(t *testing.T) {
+	_, file, info := checkSource(t, `package p
+
+type ints []int
+
+func F(i int, f float64, s string, b bool, xs ints) {
+	_, _, _, _, _ = i, f, s, b, xs
+}
+`)
+	tests := []struct {
+		operand string
+		op      token.Token
+		want    bool
+	}{
+		{"i", token.ADD, true},
+		{"i", token.REM, true},
+		{"i", token.SHL, true},
+		{"i", token.LSS, true},
+		{"f", token.QUO, true},
+		{"f", token.REM, false},
+		{"f", token.AND_NOT, false},
+		{"s", token.ADD, true},
+		{"s", token.SUB, false},
+		{"s", token.GEQ, true},
+		{"b", token.ADD, false},
+		{"b", token.LSS, false},
+		{"b", token.EQL, true},
+		// Only basic types are checked
+		{"xs", token.ADD, true},
+	}
+	for _, test := range tests {
+		// The operands are the identifiers in the assignment, after the parameters
+		x := findIdent(file, test.operand, 1)
+		if got := validOperator(info, x, test.op); got != test.want {
+			t.Errorf("validOperator(%s %s) = %v, want %v", test.operand, test.op, got, test.want)
+		}
+	}
+
+	if !validOperator(nil, ast.NewIdent("x"), token.SUB) {
+		t.Error("validOperator without type information = false, want true")
+	}
+	if !validOperator(info, ast.NewIdent("x"), token.SUB) {
+		t.Error("validOperator of an operand without a type = false, want true")
+	}
+}
+
+func TestCountUses(t *testing.T) {
+	_, file, info := checkSource(t, `package p
+
+import "strings"
+
+var global int
+
+type T struct{ f int }
+
+func F(param int) (result int) {
+	a := strings.ToUpper("a")
+	b, c := 1, 2
+	b = c
+	c = b
+	var t T
+	t.f = param
+	d := []int{global}
+	d[0] = 1
+	e := 0
+	e += 1
+	e++
+	return len(a) + t.f + d[0] + result
+}
+`)
+	want := map[string]int{
+		"strings": 1,
+		"a":       1,
+		// Assignments with = don't count as uses
+		"b": 1,
+		"c": 1,
+		"t": 2,
+		"d": 2,
+		// Unlike compound assignments and increments
+		"e": 2,
+	}
+	got := make(map[string]int)
+	for obj, n := range countUses(info, file) {
+		got[obj.Name()] = n
+	}
+	if len(got) != len(want) {
+		t.Errorf("got uses of %v, want %v", got, want)
+	}
+	for name, n := range want {
+		if got[name] != n {
+			t.Errorf("got %d uses of %s, want %d", got[name], name, n)
+		}
+	}
+}
+
+func TestUsesLast(t *testing.T) {
+	_, file, info := checkSource(t, `package p
+
+import "strings"
+
+func F(s string) string {
+	x := strings.ToUpper(s)
+	y := x
+	y = strings.TrimSpace(" y ")
+	return y + x
+}
+`)
+	uses := countUses(info, file)
+	body := file.Decls[1].(*ast.FuncDecl).Body.List
+	tests := []struct {
+		name string
+		node ast.Node
+		want bool
+	}{
+		// strings is still used by the assignment to y
+		{"declaration of x", body[0], false},
+		{"declaration of y", body[1], false},
+		// The assignment to y doesn't count as a use of it
+		{"assignment to y", body[2], false},
+		{"return", body[3], true},
+		{"first operand", body[3].(*ast.ReturnStmt).Results[0].(*ast.BinaryExpr).X, true},
+		{"parameter", findIdent(file, "s", 1), false},
+	}
+	for _, test := range tests {
+		if got := usesLast(info, uses, test.node); got != test.want {
+			t.Errorf("usesLast(%s) = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if usesLast(info, nil, body[3]) {
+		t.Error("usesLast without uses = true, want false")
+	}
+}
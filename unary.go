@@ -0,0 +1,113 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// UnaryVisitor finds negations that can be removed, and operands that can be
+// negated: numbers with - and booleans with !. Conditions are left to the
+// negate-conditionals category, and operands are only negated when type
+// information is available.
+type UnaryVisitor struct {
+	// Info is used to find the type of operands, if non-nil
+	Info *types.Info
+
+	// Mutants is a list of mutants discovered by the visitor
+	Mutants []Mutant
+}
+
+func (v *UnaryVisitor) Visit(node ast.Node) ast.Visitor {
+	for _, x := range operands(node) {
+		v.mutate(x)
+	}
+	return v
+}
+
+// operands returns pointers to the expressions directly within node that are
+// used as values, other than conditions
+func operands(node ast.Node) []*ast.Expr {
+	var xs []*ast.Expr
+	addList := func(list []ast.Expr) {
+		for i := range list {
+			xs = append(xs, &list[i])
+		}
+	}
+	switch n := node.(type) {
+	case *ast.BinaryExpr:
+		xs = append(xs, &n.X, &n.Y)
+	case *ast.ParenExpr:
+		xs = append(xs, &n.X)
+	case *ast.CallExpr:
+		addList(n.Args)
+	case *ast.IndexExpr:
+		xs = append(xs, &n.Index)
+	case *ast.KeyValueExpr:
+		xs = append(xs, &n.Value)
+	case *ast.CompositeLit:
+		for i, elt := range n.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); !ok {
+				xs = append(xs, &n.Elts[i])
+			}
+		}
+	case *ast.ReturnStmt:
+		addList(n.Results)
+	case *ast.AssignStmt:
+		addList(n.Rhs)
+	case *ast.ValueSpec:
+		addList(n.Values)
+	case *ast.SendStmt:
+		xs = append(xs, &n.Value)
+	}
+	return xs
+}
+
+// mutate records the mutations of the operand pointed to by x
+func (v *UnaryVisitor) mutate(x *ast.Expr) {
+	orig := *x
+	if exp, ok := orig.(*ast.UnaryExpr); ok && (exp.Op == token.SUB || exp.Op == token.NOT) {
+		v.replace(x, exp.X)
+		return
+	}
+
+	if v.Info == nil {
+		return
+	}
+	// Negating larger expressions would mostly duplicate the operator mutations
+	switch orig.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.IndexExpr:
+	default:
+		return
+	}
+	// Negated constants may overflow, and the literal category covers them
+	tv, ok := v.Info.Types[orig]
+	if !ok || !tv.IsValue() || tv.Value != nil {
+		return
+	}
+	switch {
+	case isBasic(tv.Type, types.IsBoolean):
+		v.replace(x, &ast.UnaryExpr{OpPos: orig.Pos(), Op: token.NOT, X: orig})
+	case isBasic(tv.Type, types.IsNumeric):
+		v.replace(x, &ast.UnaryExpr{OpPos: orig.Pos(), Op: token.SUB, X: orig})
+	}
+}
+
+// replace records a mutation that replaces the expression pointed to by x with repl
+func (v *UnaryVisitor) replace(x *ast.Expr, repl ast.Expr) {
+	orig := *x
+	v.Mutants = append(v.Mutants, Mutant{
+		Pos:         orig.Pos(),
+		Category:    "unary",
+		Original:    types.ExprString(orig),
+		Replacement: types.ExprString(repl),
+		Apply:       func() { *x = repl },
+		Revert:      func() { *x = orig },
+	})
+}
+
+func unaryMutants(file *ast.File, info *types.Info) []Mutant {
+	v := UnaryVisitor{Info: info}
+	ast.Walk(&v, file)
+	return v.Mutants
+}
@@ -1,50 +1,169 @@
-package main
+package mutator
 
 import (
-	"io"
-	"os"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 )
 
-// copyDir non-recursively copies the contents of the directory src to the directory dst
-func copyDir(src, dst string) error {
-	dir, err := os.Open(src)
+// writeOverlay writes a file suitable for go build -overlay to path, replacing the
+// contents of each file in replace with the file it maps to
+func writeOverlay(path string, replace map[string]string) error {
+	data, err := json.Marshal(struct{ Replace map[string]string }{replace})
 	if err != nil {
 		return err
 	}
+	return ioutil.WriteFile(path, data, 0644)
+}
 
-	contents, err := dir.Readdir(0)
-	if err != nil {
-		return err
+// diffContext is the number of unchanged lines shown around a change by Diff
+const diffContext = 3
+
+// Diff returns a unified diff between old and new, the contents of the file name.
+// It returns "" if they're the same.
+func Diff(name string, old, new []byte) string {
+	ops := diffLines(splitLines(old), splitLines(new))
+
+	// Line numbers in old and new before each operation
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
 	}
 
-	for _, f := range contents {
-		if f.IsDir() || f.Mode()&os.ModeType > 0 {
+	var buf strings.Builder
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
 			continue
 		}
-		if err := copyFile(filepath.Join(src, f.Name()), dst); err != nil {
-			return err
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", name, name)
+		}
+
+		// Changes separated by few enough unchanged lines share a hunk
+		last := k
+		for j := k + 1; j < len(ops) && j-last-1 <= 2*diffContext; j++ {
+			if ops[j].kind != ' ' {
+				last = j
+			}
+		}
+		start, end := k-diffContext, last+1+diffContext
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ops) {
+			end = len(ops)
 		}
+
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", aLine[start]+1, aLine[end]-aLine[start],
+			bLine[start]+1, bLine[end]-bLine[start])
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.Write(bytes.TrimSuffix(op.line, []byte("\n")))
+			buf.WriteString("\n")
+		}
+		k = end
 	}
+	return buf.String()
+}
 
-	return nil
+// diffOp is a line of a diff, which is kept (' '), removed ('-') or added ('+')
+type diffOp struct {
+	kind byte
+	line []byte
 }
 
-// copyFile copies the file given by src to the directory dir
-func copyFile(src, dir string) error {
-	name := filepath.Base(src)
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return err
+// maxDiffCells limits the size of the table used by diffLines to find the
+// lines in common, beyond which the changed region is replaced as a whole
+const maxDiffCells = 1 << 22
+
+// diffLines returns the operations that turn a into b, keeping the longest
+// common subsequence of lines between their first and last differences
+func diffLines(a, b [][]byte) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && bytes.Equal(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && bytes.Equal(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
 	}
-	defer srcFile.Close()
 
-	dstFile, err := os.Create(filepath.Join(dir, name))
-	if err != nil {
-		return err
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(x), len(y)
+	var lcs []int32
+	if n*m <= maxDiffCells {
+		// lcs[i*(m+1)+j] is the length of the longest common subsequence of x[i:] and y[j:]
+		lcs = make([]int32, (n+1)*(m+1))
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				switch {
+				case bytes.Equal(x[i], y[j]):
+					lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+				case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+					lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j]
+				default:
+					lcs[i*(m+1)+j] = lcs[i*(m+1)+j+1]
+				}
+			}
+		}
+	}
+	i, j := 0, 0
+	for lcs != nil && i < n && j < m {
+		switch {
+		case bytes.Equal(x[i], y[j]):
+			ops = append(ops, diffOp{' ', x[i]})
+			i, j = i+1, j+1
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			ops = append(ops, diffOp{'-', x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', y[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', x[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', y[j]})
 	}
-	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func splitLines(data []byte) [][]byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// relPath returns filename relative to baseDir with forward slashes, or filename
+// itself if baseDir is empty or doesn't contain it
+func relPath(baseDir, filename string) string {
+	if baseDir != "" {
+		if rel, err := filepath.Rel(baseDir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filename)
 }
@@ -0,0 +1,323 @@
+package mutator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchDelta describes how the outcomes of a package's mutants changed after
+// the functions that were edited were tested again
+type WatchDelta struct {
+	// Funcs are the names of the functions that were tested again. Functions
+	// named init or _ are numbered by file, as in init@a.go#1.
+	Funcs []string
+
+	// Survived are the mutants that survived and didn't before, including new ones,
+	// and Killed are those that were killed after surviving before
+	Survived, Killed []Result
+
+	// Summary counts the current outcomes of all the package's mutants
+	Summary Summary
+}
+
+// Watch tests the mutants of the named package, then polls its files every interval
+// and tests the mutants of the functions that changed again, until ctx is done.
+// If code outside of functions or a test file changes, every mutant is tested
+// again. Each run is described to report, which for the first run has every
+// surviving mutant as newly surviving.
+func (r *Runner) Watch(ctx context.Context, name string, interval time.Duration, report func(WatchDelta)) error {
+	pkg, err := LoadPackage(r.Build, name)
+	if err != nil {
+		return err
+	}
+	state, err := watchState(pkg.Dir)
+	if err != nil {
+		return err
+	}
+
+	base := r.Changed
+	defer func() { r.Changed = base }()
+
+	results := make(map[string]Result)
+	var prev *packageFuncs
+	for {
+		funcs := collectFuncs(pkg)
+		changed := funcs.changed(prev)
+
+		r.Changed = base
+		if changed != nil {
+			r.Changed = intersectLines(base, funcs.lines(changed))
+		}
+		var collect resultCollector
+		reporter := r.Reporter
+		r.Reporter = &collect
+		if reporter != nil {
+			r.Reporter = MultiReporter{reporter, &collect}
+		}
+		_, err := r.runPackage(ctx, pkg)
+		r.Reporter = reporter
+		if errors.Is(err, context.Canceled) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		delta := WatchDelta{Funcs: changed, Summary: make(Summary)}
+		if changed == nil {
+			delta.Funcs = funcs.names()
+		}
+		tested := make(map[string]bool)
+		for _, fn := range delta.Funcs {
+			tested[fn] = true
+		}
+
+		// Results of the functions that weren't tested again are kept
+		next := make(map[string]Result)
+		if changed != nil {
+			for key, result := range results {
+				if fn := keyFunc(key); !tested[fn] && (fn == "" || funcs.src[fn] != "") {
+					next[key] = result
+				}
+			}
+		}
+		sortResults(collect.results)
+		for key, result := range funcs.keys(collect.results) {
+			old, ok := results[key]
+			if ok && old.Outcome == Survived && result.Outcome != Survived {
+				delta.Killed = append(delta.Killed, result)
+			} else if result.Outcome == Survived && (!ok || old.Outcome != Survived) {
+				delta.Survived = append(delta.Survived, result)
+			}
+			next[key] = result
+		}
+		results = next
+		for _, result := range results {
+			delta.Summary[result.Outcome]++
+		}
+		sortResults(delta.Survived)
+		sortResults(delta.Killed)
+		report(delta)
+		prev = funcs
+
+		// Wait for the files to change, and for the package to load again
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+			current, err := watchState(pkg.Dir)
+			if err != nil {
+				return err
+			}
+			if current == state {
+				continue
+			}
+			state = current
+			if pkg, err = LoadPackage(r.Build, name); err != nil {
+				r.logf(LevelNormal, "%s\n", err)
+				continue
+			}
+			break
+		}
+	}
+}
+
+// watchState returns a description of the Go files in dir which changes when they do
+func watchState(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", path, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// packageFuncs holds the source of the functions in a package, which is used to
+// find the functions that changed between two versions of the package. Functions
+// are identified by their names, apart from init and _ functions, which may be
+// declared several times and are told apart by their file and their order in it.
+type packageFuncs struct {
+	fset *token.FileSet
+
+	// src maps function names to their source, and other holds the source
+	// outside of functions and of the test files
+	src   map[string]string
+	other string
+
+	// decls maps function names to their declaration, and files to the name of
+	// the file it's in
+	decls map[string]*ast.FuncDecl
+	files map[string]string
+}
+
+// collectFuncs returns the functions of pkg
+func collectFuncs(pkg *Package) *packageFuncs {
+	funcs := &packageFuncs{
+		fset:  pkg.Fset,
+		src:   make(map[string]string),
+		decls: make(map[string]*ast.FuncDecl),
+		files: make(map[string]string),
+	}
+	var other strings.Builder
+	for _, file := range pkg.Files {
+		name := pkg.Fset.File(file.Pos()).Name()
+		seen := make(map[string]int)
+		for _, decl := range file.Decls {
+			var buf strings.Builder
+			printer.Fprint(&buf, pkg.Fset, decl)
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				other.WriteString(buf.String())
+				continue
+			}
+			fnName := funcName(fn)
+			if fnName == "init" || fnName == "_" {
+				seen[fnName]++
+				fnName = fmt.Sprintf("%s@%s#%d", fnName, filepath.Base(name), seen[fnName])
+			}
+			funcs.src[fnName] = buf.String()
+			funcs.decls[fnName] = fn
+			funcs.files[fnName] = name
+		}
+	}
+	for _, names := range [][]string{pkg.TestGoFiles, pkg.XTestGoFiles} {
+		for _, name := range names {
+			data, _ := ioutil.ReadFile(filepath.Join(pkg.Dir, name))
+			other.Write(data)
+		}
+	}
+	funcs.other = other.String()
+	return funcs
+}
+
+// changed returns the names of the functions that were added or changed since prev,
+// or nil if prev is nil or anything else changed
+func (f *packageFuncs) changed(prev *packageFuncs) []string {
+	if prev == nil || f.other != prev.other {
+		return nil
+	}
+	changed := []string{}
+	for _, name := range f.names() {
+		if f.src[name] != prev.src[name] {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// names returns the sorted names of the functions
+func (f *packageFuncs) names() []string {
+	var names []string
+	for name := range f.src {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lines returns the lines spanned by the named functions
+func (f *packageFuncs) lines(names []string) ChangedLines {
+	lines := make(ChangedLines)
+	for _, name := range names {
+		file := f.files[name]
+		if lines[file] == nil {
+			lines[file] = make(map[int]bool)
+		}
+		fn := f.decls[name]
+		for l := f.fset.Position(fn.Pos()).Line; l <= f.fset.Position(fn.End()).Line; l++ {
+			lines[file][l] = true
+		}
+	}
+	return lines
+}
+
+// at returns the name of the function containing pos, or "" if there is none
+func (f *packageFuncs) at(pos token.Position) string {
+	for name, fn := range f.decls {
+		start, end := f.fset.Position(fn.Pos()), f.fset.Position(fn.End())
+		if f.files[name] == pos.Filename && start.Offset <= pos.Offset && pos.Offset < end.Offset {
+			return name
+		}
+	}
+	return ""
+}
+
+// keyFunc returns the name of the function of the mutant with the given key
+func keyFunc(key string) string {
+	return strings.SplitN(key, " ", 2)[0]
+}
+
+// keys returns results by keys identifying their mutants independently of their
+// position, so the same mutant can be found after the lines around it change
+func (f *packageFuncs) keys(results []Result) map[string]Result {
+	keyed := make(map[string]Result)
+	seen := make(map[string]int)
+	for _, result := range results {
+		m := result.Mutant
+		key := fmt.Sprintf("%s %s %s -> %s", f.at(result.Position), m.Category, m.Original, m.Replacement)
+		seen[key]++
+		keyed[fmt.Sprintf("%s #%d", key, seen[key])] = result
+	}
+	return keyed
+}
+
+// intersectLines returns the lines in both a and b. If a is nil, b is returned.
+func intersectLines(a, b ChangedLines) ChangedLines {
+	if a == nil {
+		return b
+	}
+	both := make(ChangedLines)
+	for file, lines := range b {
+		for line := range lines {
+			if a.Contains(token.Position{Filename: file, Line: line}) {
+				if both[file] == nil {
+					both[file] = make(map[int]bool)
+				}
+				both[file][line] = true
+			}
+		}
+	}
+	return both
+}
+
+// resultCollector is a Reporter keeping every result, in the order they're tested
+type resultCollector struct {
+	mu      sync.Mutex
+	results []Result
+}
+
+func (c *resultCollector) Report(result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, result)
+}
+
+// sortResults sorts results by their position
+func sortResults(results []Result) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i].Position, results[j].Position
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+}
@@ -0,0 +1,74 @@
+package mutator
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// parseFuncs returns the functions of a package made of the given files
+func parseFuncs(t *testing.T, files map[string]string) *packageFuncs {
+	t.Helper()
+	pkg := &Package{Package: &build.Package{Dir: "/src/p"}, Fset: token.NewFileSet()}
+	for _, name := range []string{"a.go", "b.go"} {
+		file, err := parser.ParseFile(pkg.Fset, "/src/p/"+name, files[name], 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg.Files = append(pkg.Files, file)
+	}
+	return collectFuncs(pkg)
+}
+
+func TestWatchChangedFuncs(t *testing.T) {
+	prev := parseFuncs(t, map[string]string{
+		"a.go": "package p\n\nfunc init() { x = 1 }\n\nfunc init() { y = 1 }\n\nfunc (T) M() {}\n",
+		"b.go": "package p\n\nvar x, y, z int\n\ntype T struct{}\n\nfunc init() { z = 1 }\n\nfunc F() {}\n",
+	})
+	if got, want := prev.names(), []string{"F", "T.M", "init@a.go#1", "init@a.go#2", "init@b.go#1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got functions %q, want %q", got, want)
+	}
+
+	funcs := parseFuncs(t, map[string]string{
+		"a.go": "package p\n\nfunc init() { x = 1 }\n\nfunc init() { y = 2 }\n\nfunc (T) M() {}\n",
+		"b.go": "package p\n\nvar x, y, z int\n\ntype T struct{}\n\nfunc init() { z = 1 }\n\nfunc F() { z++ }\n",
+	})
+	changed := funcs.changed(prev)
+	if want := []string{"F", "init@a.go#2"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("got changed functions %q, want %q", changed, want)
+	}
+	want := ChangedLines{"/src/p/a.go": {5: true}, "/src/p/b.go": {9: true}}
+	if got := funcs.lines(changed); !reflect.DeepEqual(got, want) {
+		t.Errorf("got changed lines %v, want %v", got, want)
+	}
+
+	var inits []*ast.FuncDecl
+	for _, name := range []string{"init@a.go#1", "init@a.go#2", "init@b.go#1"} {
+		inits = append(inits, funcs.decls[name])
+	}
+	for i, fn := range inits {
+		if got := funcs.at(funcs.fset.Position(fn.Body.Pos())); got != funcs.names()[i+2] {
+			t.Errorf("got function %q at init %d, want %q", got, i, funcs.names()[i+2])
+		}
+	}
+}
+
+func TestWatchOtherChanges(t *testing.T) {
+	prev := parseFuncs(t, map[string]string{
+		"a.go": "package p\n\nfunc F() {}\n",
+		"b.go": "package p\n\nvar x int\n",
+	})
+	funcs := parseFuncs(t, map[string]string{
+		"a.go": "package p\n\nfunc F() {}\n",
+		"b.go": "package p\n\nvar x = 1\n",
+	})
+	if changed := funcs.changed(prev); changed != nil {
+		t.Errorf("got changed functions %q after a change outside them, want all", changed)
+	}
+	if changed := prev.changed(nil); changed != nil {
+		t.Errorf("got changed functions %q without a previous version, want all", changed)
+	}
+}